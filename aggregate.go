@@ -5,12 +5,21 @@ package errors
 
 //goland:noinspection SpellCheckingInspection
 import (
-	mapset "github.com/deckarep/golang-set"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
 )
 
 // Aggregate 表示包含多个错误的对象, 但不一定具有单一的语义含义。
 // Aggregate 可以与 errors.Is() 一起使用, 以检查特定错误类型的发生。
-// errors.As() 不支持, 因为调用者可能关心与给定类型匹配的潜在多个特定错误。
+// NewAggregate 返回的 Aggregate 也可以与 errors.As() 一起使用, 此时按访问顺序返回第一个匹配的 error。
+// 调用者关心与给定类型匹配的所有 error 时, 应当使用 AsAll。
 type Aggregate interface {
 	error
 	Errors() []error
@@ -20,6 +29,7 @@ type Aggregate interface {
 // NewAggregate 将 errList 转换为 Aggregate, Aggregate 本身就是 errors 接口的实现。
 // 如果 slice 为空, 则返回 nil。
 // 它将检查输入 errList 的任何元素是否为 nil, 以避免调用 Error() 时出现 nil panic。
+// errList 中标准库 errors.Join 的结果会被展开, 其中的 error 直接成为 Aggregate 的元素。
 func NewAggregate(errList ...error) Aggregate {
 	if len(errList) == 0 {
 		return nil
@@ -27,6 +37,10 @@ func NewAggregate(errList ...error) Aggregate {
 	// 确保 errList 不包含 nil
 	var errs []error
 	for _, e := range errList {
+		if isJoinError(e) {
+			errs = append(errs, children(e)...)
+			continue
+		}
 		if e != nil {
 			errs = append(errs, e)
 		}
@@ -40,35 +54,105 @@ func NewAggregate(errList ...error) Aggregate {
 // aggregate 实现了 error 与 Aggregate 接口。
 type aggregate []error
 
+// AggregateLimits 限制 Aggregate.Error() 输出的长度, 适用于包含大量 error 的 Aggregate。
+// 超出限制的 error 不再输出, 而是以 "and N more" 说明省略的数量。
+type AggregateLimits struct {
+	// MaxErrors 最多输出的 error 数量 (去重后), 0 表示不限制
+	MaxErrors int
+
+	// MaxLength 输出的最大字节数 (不包括 "and N more" 部分), 0 表示不限制。
+	// 至少会输出一个 error, 过长时将被截断并以 "..." 结尾
+	MaxLength int
+}
+
+var aggregateLimits atomic.Value
+
+// SetAggregateLimits 设置全局的 AggregateLimits, 默认不限制。
+func SetAggregateLimits(limits AggregateLimits) {
+	aggregateLimits.Store(limits)
+}
+
+// GetAggregateLimits 返回当前全局的 AggregateLimits。
+func GetAggregateLimits() AggregateLimits {
+	limits, _ := aggregateLimits.Load().(AggregateLimits)
+	return limits
+}
+
 // Error error 接口的一部分
+// 重复的 error 消息只输出一次, 输出受 AggregateLimits 的限制。
 func (agg aggregate) Error() string {
 	if len(agg) == 0 {
 		panic("error slice is empty")
 	}
+
+	limits := GetAggregateLimits()
 	if len(agg) == 1 {
-		return agg[0].Error()
+		return truncate(agg[0].Error(), limits.MaxLength)
 	}
 
-	var result string
+	var (
+		b     strings.Builder
+		shown int
+		more  int
+		full  bool
+	)
 	// 不需要线程安全的 set
-	seenErrs := mapset.NewThreadUnsafeSet()
+	seenErrs := map[string]struct{}{}
 
 	agg.visit(func(err error) bool {
 		msg := err.Error()
-		if seenErrs.Contains(msg) {
+		if _, ok := seenErrs[msg]; ok {
 			return false
 		}
-		seenErrs.Add(msg)
-		if seenErrs.Cardinality() > 1 {
-			result += ", "
+		seenErrs[msg] = struct{}{}
+
+		if !full && limits.MaxErrors > 0 && shown >= limits.MaxErrors {
+			full = true
+		}
+		if !full && limits.MaxLength > 0 && shown > 0 && b.Len()+len(", ")+len(msg) > limits.MaxLength {
+			full = true
+		}
+		if full {
+			more++
+			return false
 		}
-		result += msg
+
+		if shown > 0 {
+			b.WriteString(", ")
+		}
+		if shown == 0 {
+			msg = truncate(msg, limits.MaxLength)
+		}
+		b.WriteString(msg)
+		shown++
 		return false
 	})
-	if seenErrs.Cardinality() == 1 {
-		return result
+
+	if more > 0 {
+		return fmt.Sprintf("[%s, and %d more]", b.String(), more)
 	}
-	return "[" + result + "]"
+	if shown == 1 {
+		return b.String()
+	}
+	return "[" + b.String() + "]"
+}
+
+// truncate 将 msg 截断为不超过 n 个字节, 并以 "..." 结尾, n 小于等于 0 时不截断。
+// 截断时不会破坏 UTF-8 字符。
+func truncate(msg string, n int) string {
+	const ellipsis = "..."
+	if n <= 0 || len(msg) <= n {
+		return msg
+	}
+	if n <= len(ellipsis) {
+		return ellipsis[:n]
+	}
+
+	i := n - len(ellipsis)
+	for i > 0 && !utf8.RuneStart(msg[i]) {
+		i--
+	}
+	return msg[:i] + ellipsis
 }
 
 func (agg aggregate) Is(target error) bool {
@@ -77,46 +161,211 @@ func (agg aggregate) Is(target error) bool {
 	})
 }
 
-func (agg aggregate) visit(f func(err error) bool) bool {
-	for _, err := range agg {
-		switch err := err.(type) {
-		case aggregate:
-			if match := err.visit(f); match {
-				return match
+// Format implements fmt.Formatter. https://golang.org/pkg/fmt/#hdr-Printing
+//
+// Verbs:
+//
+//	%s      与 Error() 相同
+//	%v      %s 的别名
+//	%q      带引号的 Error()
+//
+// Flags:
+//
+//	#      JSON 数组格式的输出, 用于日志记录。每个元素为对应 error 在 withCode 中的 JSON 格式,
+//	       嵌套的 Aggregate 为嵌套的数组
+//	-      以 Error() 为首行, 逐个输出每个 error 的调用者详细信息
+//	+      以 Error() 为首行, 逐个输出每个 error 的完整错误堆栈详细信息, 对调试有用
+//
+// 使用 - 或 + 时, 每个 error 以其序号开头, 其余行缩进; 嵌套的 Aggregate 输出为缩进的子树。
+//
+//goland:noinspection GoUnhandledErrorResult
+func (agg aggregate) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('#') {
+			b, _ := json.Marshal(formatAggregateJSON(agg, s.Flag('-'), s.Flag('+')))
+			s.Write(b)
+			return
+		}
+		if s.Flag('+') || s.Flag('-') {
+			format := "%"
+			if s.Flag('+') {
+				format += "+"
 			}
-		case Aggregate:
-			for _, nestedErr := range err.Errors() {
-				if match := f(nestedErr); match {
-					return match
-				}
+			if s.Flag('-') {
+				format += "-"
 			}
-		default:
-			if match := f(err); match {
+			io.WriteString(s, formatAggregate(agg.Error(), agg, format+"v"))
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, agg.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", agg.Error())
+	}
+}
+
+// members 返回 error 树中包含多个 error 的节点 (见 isMulti) 的直接子节点, err 为其他 error 时返回 false。
+// 携带自身错误码的 Aggregate (例如 ValidationError) 作为一个整体, 同样返回 false, 以免丢失其错误码。
+func members(err error) ([]error, bool) {
+	if _, ok := errorCode(err); ok || !isMulti(err) {
+		return nil, false
+	}
+	return children(err), true
+}
+
+// formatAggregate 以 header 为首行, 使用 format 逐个格式化 errs 中的每一个 error, 嵌套的 Aggregate 输出为缩进的子树。
+func formatAggregate(header string, errs []error, format string) string {
+	var b strings.Builder
+	b.WriteString(header)
+	for i, err := range errs {
+		var text string
+		if m, ok := members(err); ok {
+			text = formatAggregate(err.Error(), m, format)
+		} else {
+			text = fmt.Sprintf(format, err)
+		}
+		fmt.Fprintf(&b, "\n[%d] %s", i, strings.Replace(text, "\n", "\n    ", -1))
+	}
+	return b.String()
+}
+
+// formatAggregateJSON 返回 errs 中每一个 error 在 withCode 中的 JSON 数据, 嵌套的 Aggregate 为嵌套的数组。
+func formatAggregateJSON(errs []error, flagDetail, flagTrace bool) []interface{} {
+	data := make([]interface{}, 0, len(errs))
+	for _, err := range errs {
+		if m, ok := members(err); ok {
+			data = append(data, formatAggregateJSON(m, flagDetail, flagTrace))
+			continue
+		}
+		if j, ok := err.(interface {
+			jsonData(flagDetail, flagTrace bool) map[string]interface{}
+		}); ok {
+			data = append(data, j.jsonData(flagDetail, flagTrace))
+			continue
+		}
+		jsonData, _ := formatChain(err, flagDetail, flagTrace, true)
+		data = append(data, jsonData)
+	}
+	return data
+}
+
+// As 按访问顺序找到第一个与 target 匹配的 error, 见 errors.As()。
+func (agg aggregate) As(target interface{}) bool {
+	return agg.visit(func(err error) bool {
+		return As(err, target)
+	})
+}
+
+// visit 按顺序对 agg 中的每一个 error 调用 f, 包含多个 error 的节点被递归展开, 见 members。
+// 当 f 返回 true 时停止访问并返回 true。
+func (agg aggregate) visit(f func(err error) bool) bool {
+	for _, err := range agg {
+		if m, ok := members(err); ok {
+			if match := aggregate(m).visit(f); match {
 				return match
 			}
+			continue
+		}
+		if match := f(err); match {
+			return match
 		}
 	}
 
 	return false
 }
 
+// AsAll 找到 err 中所有与 target 的元素类型匹配的 error, 并依次追加到 target 指向的切片中。
+// AsAll 按深度优先的前序访问 err 树, 被包装的与嵌套的 Aggregate 中的每一个 error 都会被访问,
+// 树的每个分支与 errors.As() 相同, 最多追加一个 error。
+// 如果至少有一个 error 匹配, 则返回 true。
+//
+// 例如, 找到所有的 *os.PathError:
+//
+//	var pathErrs []*os.PathError
+//	if errors.AsAll(err, &pathErrs) {
+//	       ...
+//	}
+//
+// 如果 target 不是指向切片的非 nil 指针, 或者切片的元素类型既不是接口也没有实现 error, AsAll 将会 panic。
+func AsAll(err error, target interface{}) bool {
+	if target == nil {
+		panic("errors: target cannot be nil")
+	}
+	val := reflect.ValueOf(target)
+	typ := val.Type()
+	if typ.Kind() != reflect.Ptr || val.IsNil() || typ.Elem().Kind() != reflect.Slice {
+		panic("errors: target must be a non-nil pointer to a slice")
+	}
+	elemType := typ.Elem().Elem()
+	if elemType.Kind() != reflect.Interface && !elemType.Implements(errorType) {
+		panic("errors: element of target must be interface or implement error")
+	}
+
+	slice := val.Elem()
+	n := slice.Len()
+	asAll(err, elemType, slice)
+	return slice.Len() > n
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// asAll 以深度优先的前序遍历 err 树, 将每个分支中第一个与 elemType 匹配的 error 追加到 slice 中。
+// 包含多个 error 的节点 (见 members) 本身不参与匹配, 而是继续访问其中的每一个 error。
+func asAll(err error, elemType reflect.Type, slice reflect.Value) {
+	if err == nil {
+		return
+	}
+	if _, ok := members(err); !ok {
+		if reflect.TypeOf(err).AssignableTo(elemType) {
+			slice.Set(reflect.Append(slice, reflect.ValueOf(err)))
+			return
+		}
+		if x, ok := err.(interface{ As(interface{}) bool }); ok && !isMulti(err) {
+			p := reflect.New(elemType)
+			if x.As(p.Interface()) {
+				slice.Set(reflect.Append(slice, p.Elem()))
+				return
+			}
+		}
+	}
+
+	for _, child := range children(err) {
+		asAll(child, elemType, slice)
+	}
+}
+
 // Errors 为 Aggregate 的一部分。
 func (agg aggregate) Errors() []error { return agg }
 
+// Unwrap 提供 Go 1.20 多 error 的兼容性, 使标准库的 errors.Is 与 errors.As 可以访问其中的每一个 error。
+func (agg aggregate) Unwrap() []error { return agg }
+
 // Matcher 用于匹配 errors。如果 errors 匹配, 则返回true。
 type Matcher func(error) bool
 
 // FilterOut 从输入错误中删除与 Matcher 匹配的错误。
 // 如果输入是非 Aggregate error, 则仅测试该错误。
-// 如果输入 Aggregate error, 错误列表将被递归处理。
+// 如果输入 Aggregate error 或标准库 errors.Join 的结果, 错误列表将被递归处理。
+// 被 Wrap、WithStack 与 WithMessage 包装的 Aggregate 同样被递归处理, 结果以相同的方式重新包装。
 //
 // 例如, 这可以用于从错误列表中删除已知的错误 (例如 io.EOF 或 os.PathNotFound )。
 func FilterOut(err error, fns ...Matcher) error {
 	if err == nil {
 		return nil
 	}
-	if agg, ok := err.(Aggregate); ok {
-		return NewAggregate(filterErrors(agg.Errors(), fns...)...)
+	if list, ok := members(err); ok {
+		return NewAggregate(filterErrors(list, fns...)...)
+	}
+	switch err.(type) {
+	case *withStack, *withMessage:
+		if cause := next(err); wrapsMulti(cause) {
+			if filtered := FilterOut(cause, fns...); filtered != nil {
+				return withCause(err, filtered)
+			}
+			return nil
+		}
 	}
 	if !matchesError(err, fns...) {
 		return err
@@ -124,6 +373,29 @@ func FilterOut(err error, fns ...Matcher) error {
 	return nil
 }
 
+// wrapsMulti 报告 err 链中是否有包含多个 error 的节点, 见 members。
+func wrapsMulti(err error) bool {
+	return walk(err, func(err error) bool {
+		_, ok := members(err)
+		return ok
+	})
+}
+
+// withCause 返回将 err 包装的 error 替换为 cause 的副本, err 必须为 *withStack 或 *withMessage。
+func withCause(err, cause error) error {
+	switch w := err.(type) {
+	case *withStack:
+		c := *w
+		c.error = cause
+		return &c
+	case *withMessage:
+		c := *w
+		c.cause = cause
+		return &c
+	}
+	panic("errors: unsupported wrapper")
+}
+
 // matchesError 如果有 Matcher 返回 true, 则返回 true
 func matchesError(err error, fns ...Matcher) bool {
 	for _, fn := range fns {
@@ -148,14 +420,15 @@ func filterErrors(list []error, fns ...Matcher) []error {
 }
 
 // Flatten 将可能嵌套 Aggregate 的 Aggregate 全部递归地压平为一个 Aggregate。
+// 嵌套的标准库 errors.Join 的结果同样会被压平。
 func Flatten(agg Aggregate) Aggregate {
 	var result []error
 	if agg == nil {
 		return nil
 	}
 	for _, err := range agg.Errors() {
-		if a, ok := err.(Aggregate); ok {
-			r := Flatten(a)
+		if m, ok := members(err); ok {
+			r := Flatten(aggregate(m))
 			if r != nil {
 				result = append(result, r.Errors()...)
 			}
@@ -183,3 +456,53 @@ func AggregateGoroutines(funcs ...func() error) Aggregate {
 	}
 	return NewAggregate(errs...)
 }
+
+// AggregateGoroutinesContext 与 AggregateGoroutines 相同, 但是:
+//
+//   - limit 大于 0 时, 最多同时运行 limit 个函数
+//   - ctx 被取消后, 不再启动尚未运行的函数, 并在结果的末尾追加 ctx.Err()
+//   - 函数中的 panic 会被转换为 error, 其中包含 panic 的值与 panic 处的堆栈
+//   - 结果中 error 的顺序与 funcs 的顺序一致
+//
+// 每个函数都会收到 ctx, 以便在 ctx 被取消时尽早返回。
+// 如果所有启动的函数均成功完成且 ctx 未被取消, 则返回 nil。
+func AggregateGoroutinesContext(ctx context.Context, limit int, funcs ...func(ctx context.Context) error) Aggregate {
+	errs := make([]error, len(funcs))
+
+	var sem chan struct{}
+	if limit > 0 {
+		sem = make(chan struct{}, limit)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		skipped bool
+	)
+	for i, f := range funcs {
+		if sem != nil {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			skipped = true
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, f func(ctx context.Context) error) {
+			defer wg.Done()
+			if sem != nil {
+				defer func() { <-sem }()
+			}
+			errs[i] = Try(func() error { return f(ctx) })
+		}(i, f)
+	}
+	wg.Wait()
+
+	if skipped {
+		errs = append(errs, ctx.Err())
+	}
+	return NewAggregate(errs...)
+}
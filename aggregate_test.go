@@ -4,9 +4,13 @@
 package errors
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"os"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -118,6 +122,17 @@ func (m mockAggregate) Errors() []error {
 	return m
 }
 
+// mockMulti 只实现了 Go 1.20 的 Unwrap() []error
+type mockMulti []error
+
+func (m mockMulti) Error() string {
+	return "mockMulti"
+}
+
+func (m mockMulti) Unwrap() []error {
+	return m
+}
+
 func (m mockAggregate) Is(target error) bool {
 	return m.visit(func(err error) bool {
 		return Is(err, target)
@@ -236,6 +251,51 @@ func TestFilterOut(t *testing.T) {
 				return true
 			},
 		},
+		{
+			name: "wrapped",
+			args: args{
+				err: Wrap(NewAggregate(io.EOF, loadConfig()), "batch"),
+				fns: []Matcher{MatchIs(io.EOF)},
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				assert.Equal(t, "batch: service configuration could not be loaded", err.Error())
+				assert.True(t, hasStack(err))
+				return true
+			},
+		},
+		{
+			name: "wrapped all filtered",
+			args: args{
+				err: WithMessage(NewAggregate(io.EOF, Wrap(io.EOF, "read")), "batch"),
+				fns: []Matcher{MatchIs(io.EOF)},
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				assert.Nil(t, err)
+				return true
+			},
+		},
+		{
+			name: "multi",
+			args: args{
+				err: mockMulti{io.EOF, loadConfig()},
+				fns: []Matcher{MatchIs(io.EOF)},
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				assert.Equal(t, "service configuration could not be loaded", err.Error())
+				return true
+			},
+		},
+		{
+			name: "coded",
+			args: args{
+				err: WithCode(NewAggregate(io.EOF, loadConfig()), errEOF, "batch"),
+				fns: []Matcher{MatchIs(io.EOF)},
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				assert.Nil(t, err)
+				return true
+			},
+		},
 		{
 			name: "err is nil",
 			args: args{
@@ -271,6 +331,13 @@ func TestFlatten(t *testing.T) {
 			},
 			want: "[EOF, service configuration could not be loaded]",
 		},
+		{
+			name: "multi",
+			args: args{
+				agg: NewAggregate(mockMulti{io.EOF, NewAggregate(loadConfig())}, io.ErrUnexpectedEOF),
+			},
+			want: "[EOF, service configuration could not be loaded, unexpected EOF]",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -364,3 +431,350 @@ func TestAggregateGoroutines_nil(t *testing.T) {
 		})
 	}
 }
+
+func Test_aggregate_As(t *testing.T) {
+	pathErr1 := &os.PathError{Op: "open", Path: "a", Err: io.EOF}
+	pathErr2 := &os.PathError{Op: "open", Path: "b", Err: io.EOF}
+	tests := []struct {
+		name string
+		err  error
+		want *os.PathError
+	}{
+		{
+			name: "first match",
+			err:  NewAggregate(io.EOF, pathErr1, pathErr2),
+			want: pathErr1,
+		},
+		{
+			name: "nested",
+			err:  NewAggregate(io.EOF, NewAggregate(io.ErrUnexpectedEOF, Wrap(pathErr2, "wrap")), pathErr1),
+			want: pathErr2,
+		},
+		{
+			name: "wrapped aggregate",
+			err:  Wrap(NewAggregate(io.EOF, pathErr1), "wrap"),
+			want: pathErr1,
+		},
+		{
+			name: "no match",
+			err:  NewAggregate(io.EOF, loadConfig()),
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var target *os.PathError
+			assert.Equal(t, tt.want != nil, As(tt.err, &target))
+			assert.Equal(t, tt.want, target)
+		})
+	}
+}
+
+func Test_aggregate_As_coded(t *testing.T) {
+	var target *withCode
+	assert.True(t, As(NewAggregate(io.EOF, loadConfig()), &target))
+	assert.Equal(t, errConfigurationNotValid, target.code)
+}
+
+func TestAsAll(t *testing.T) {
+	pathErr1 := &os.PathError{Op: "open", Path: "a", Err: io.EOF}
+	pathErr2 := &os.PathError{Op: "open", Path: "b", Err: io.EOF}
+	pathErr3 := &os.PathError{Op: "open", Path: "c", Err: io.EOF}
+	tests := []struct {
+		name string
+		err  error
+		want []*os.PathError
+	}{
+		{
+			name: "aggregate",
+			err:  NewAggregate(pathErr1, io.EOF, pathErr2),
+			want: []*os.PathError{pathErr1, pathErr2},
+		},
+		{
+			name: "nested",
+			err:  NewAggregate(pathErr1, NewAggregate(Wrap(pathErr2, "wrap"), mockAggregate{pathErr3})),
+			want: []*os.PathError{pathErr1, pathErr2, pathErr3},
+		},
+		{
+			name: "wrapped",
+			err:  Wrap(NewAggregate(pathErr1, io.EOF, pathErr2), "batch"),
+			want: []*os.PathError{pathErr1, pathErr2},
+		},
+		{
+			name: "wrapped nested",
+			err:  NewAggregate(pathErr1, WithMessage(NewAggregate(pathErr2, Wrap(mockAggregate{pathErr3}, "wrap")), "batch")),
+			want: []*os.PathError{pathErr1, pathErr2, pathErr3},
+		},
+		{
+			name: "not aggregate",
+			err:  Wrap(pathErr1, "wrap"),
+			want: []*os.PathError{pathErr1},
+		},
+		{
+			name: "no match",
+			err:  NewAggregate(io.EOF),
+			want: nil,
+		},
+		{
+			name: "nil",
+			err:  nil,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []*os.PathError
+			assert.Equal(t, tt.want != nil, AsAll(tt.err, &got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAsAll_interface(t *testing.T) {
+	var got []interface{ Code() int }
+	assert.False(t, AsAll(NewAggregate(io.EOF, loadConfig()), &got))
+
+	var errs []error
+	assert.True(t, AsAll(NewAggregate(io.EOF, loadConfig()), &errs))
+	assert.Len(t, errs, 2)
+}
+
+func TestAsAll_panic(t *testing.T) {
+	var pathErr *os.PathError
+	var notErrs []string
+	tests := []struct {
+		name   string
+		target interface{}
+	}{
+		{name: "nil", target: nil},
+		{name: "not pointer", target: []error{}},
+		{name: "nil pointer", target: (*[]error)(nil)},
+		{name: "not slice", target: &pathErr},
+		{name: "not error", target: &notErrs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Panics(t, func() { AsAll(io.EOF, tt.target) })
+		})
+	}
+}
+
+func TestAggregateGoroutinesContext(t *testing.T) {
+	err1 := New("err-1")
+	err2 := New("err-2")
+	agg := AggregateGoroutinesContext(context.Background(), 0,
+		func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			return err1
+		},
+		func(ctx context.Context) error { return nil },
+		func(ctx context.Context) error { return err2 },
+	)
+	assert.Equal(t, []error{err1, err2}, agg.Errors())
+
+	assert.Nil(t, AggregateGoroutinesContext(context.Background(), 1,
+		func(ctx context.Context) error { return nil },
+		func(ctx context.Context) error { return nil },
+	))
+	assert.Nil(t, AggregateGoroutinesContext(context.Background(), 1))
+}
+
+func TestAggregateGoroutinesContext_limit(t *testing.T) {
+	var running, max int32
+	funcs := make([]func(ctx context.Context) error, 10)
+	for i := range funcs {
+		funcs[i] = func(ctx context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		}
+	}
+	assert.Nil(t, AggregateGoroutinesContext(context.Background(), 3, funcs...))
+	assert.True(t, max <= 3, "max parallelism %d", max)
+}
+
+func TestAggregateGoroutinesContext_cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var started int32
+	err1 := New("err-1")
+	agg := AggregateGoroutinesContext(ctx, 1,
+		func(ctx context.Context) error {
+			atomic.AddInt32(&started, 1)
+			cancel()
+			return err1
+		},
+		func(ctx context.Context) error {
+			atomic.AddInt32(&started, 1)
+			return nil
+		},
+	)
+	assert.Equal(t, int32(1), started)
+	assert.Equal(t, []error{err1, context.Canceled}, agg.Errors())
+
+	agg = AggregateGoroutinesContext(ctx, 0, func(ctx context.Context) error { return nil })
+	assert.Equal(t, []error{context.Canceled}, agg.Errors())
+}
+
+func TestAggregateGoroutinesContext_panic(t *testing.T) {
+	agg := AggregateGoroutinesContext(context.Background(), 0,
+		func(ctx context.Context) error { return nil },
+		func(ctx context.Context) error { panic("boom") },
+		func(ctx context.Context) error { panic(io.EOF) },
+		func(ctx context.Context) error {
+			var m map[string]int
+			m["a"] = 1
+			return nil
+		},
+	)
+	errs := agg.Errors()
+	assert.Len(t, errs, 3)
+	assert.Equal(t, "panic: boom", errs[0].Error())
+	assert.Equal(t, "boom", errs[0].(*panicError).Value())
+	assert.True(t, Is(errs[1], io.EOF))
+	assert.Contains(t, errs[2].Error(), "assignment to entry in nil map")
+
+	for _, err := range errs {
+		assert.Equal(t, "github.com/eachinchung/errors.TestAggregateGoroutinesContext_panic", StackTraceOf(err)[0].name()[:len("github.com/eachinchung/errors.TestAggregateGoroutinesContext_panic")])
+	}
+}
+
+func Test_aggregate_Format(t *testing.T) {
+	agg := NewAggregate(Code(errEOF, "read failed"), NewAggregate(io.EOF, io.ErrUnexpectedEOF))
+
+	assert.Equal(t, "[read failed, EOF, unexpected EOF]", fmt.Sprintf("%s", agg))
+	assert.Equal(t, "[read failed, EOF, unexpected EOF]", fmt.Sprintf("%v", agg))
+	assert.Equal(t, `"[read failed, EOF, unexpected EOF]"`, fmt.Sprintf("%q", agg))
+
+	assert.Regexp(t, `^\[read failed, EOF, unexpected EOF\]
+\[0\] read failed - #0 \[.+/errors/aggregate_test.go:\d+ \(github.com/eachinchung/errors.Test_aggregate_Format\)\] \(4\) end of input
+\[1\] \[EOF, unexpected EOF\]
+    \[0\] EOF
+    \[1\] unexpected EOF$`, fmt.Sprintf("%-v", agg))
+
+	got := fmt.Sprintf("%+v", NewAggregate(New("ooh"), io.EOF))
+	assert.Regexp(t, `^\[ooh, EOF\]
+\[0\] ooh
+    github.com/eachinchung/errors.Test_aggregate_Format
+    	.+/errors/aggregate_test.go:\d+
+(    .+\n)+\[1\] EOF$`, got)
+}
+
+func Test_aggregate_FormatJSON(t *testing.T) {
+	agg := NewAggregate(Code(errEOF, "read failed"), NewAggregate(io.EOF, loadConfig()))
+
+	assert.Equal(t,
+		`[[{"error":"end of input"}],[[{"error":"EOF"}],[{"error":"configuration not valid error"}]]]`,
+		fmt.Sprintf("%#v", agg))
+
+	member := loadConfig()
+	got := fmt.Sprintf("%#+v", NewAggregate(member))
+	assert.Equal(t, "["+fmt.Sprintf("%#+v", member)+"]", got)
+
+	got = fmt.Sprintf("%#-v", NewAggregate(member, io.EOF))
+	assert.Equal(t, "["+fmt.Sprintf("%#-v", member)+`,[{"caller":"#0","code":1,"error":"EOF","message":"EOF"}]]`, got)
+}
+
+func Test_aggregate_Error_limits(t *testing.T) {
+	defer SetAggregateLimits(AggregateLimits{})
+
+	errs := aggregate{New("err-1"), New("err-2"), New("err-2"), New("err-3"), New("err-4")}
+	tests := []struct {
+		name   string
+		limits AggregateLimits
+		agg    aggregate
+		want   string
+	}{
+		{
+			name: "no limits",
+			agg:  errs,
+			want: "[err-1, err-2, err-3, err-4]",
+		},
+		{
+			name:   "max errors",
+			limits: AggregateLimits{MaxErrors: 2},
+			agg:    errs,
+			want:   "[err-1, err-2, and 2 more]",
+		},
+		{
+			name:   "max errors one",
+			limits: AggregateLimits{MaxErrors: 1},
+			agg:    errs,
+			want:   "[err-1, and 3 more]",
+		},
+		{
+			name:   "max errors not reached",
+			limits: AggregateLimits{MaxErrors: 4},
+			agg:    errs,
+			want:   "[err-1, err-2, err-3, err-4]",
+		},
+		{
+			name:   "max length",
+			limits: AggregateLimits{MaxLength: 14},
+			agg:    errs,
+			want:   "[err-1, err-2, and 2 more]",
+		},
+		{
+			name:   "max length truncates first error",
+			limits: AggregateLimits{MaxLength: 4},
+			agg:    errs,
+			want:   "[e..., and 3 more]",
+		},
+		{
+			name:   "max length single error",
+			limits: AggregateLimits{MaxLength: 8},
+			agg:    aggregate{New("内部服务器错误")},
+			want:   "内...",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetAggregateLimits(tt.limits)
+			assert.Equal(t, tt.want, tt.agg.Error())
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", truncate("abc", 0))
+	assert.Equal(t, "abc", truncate("abc", 3))
+	assert.Equal(t, "..", truncate("abcd", 2))
+	assert.Equal(t, "a...", truncate("abcde", 4))
+	assert.Equal(t, "...", truncate("错误", 5))
+	assert.Equal(t, "错...", truncate("错误码", 6))
+	assert.Equal(t, "错误", truncate("错误", 6))
+}
+
+func benchmarkAggregateError(b *testing.B, n int, limits AggregateLimits) {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = fmt.Errorf("row %d: invalid value", i)
+	}
+	agg := NewAggregate(errs...)
+
+	SetAggregateLimits(limits)
+	defer SetAggregateLimits(AggregateLimits{})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = agg.Error()
+	}
+}
+
+func BenchmarkAggregateError(b *testing.B) {
+	for _, n := range []int{10, 1000, 100000} {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			benchmarkAggregateError(b, n, AggregateLimits{})
+		})
+		b.Run(fmt.Sprintf("%d-limited", n), func(b *testing.B) {
+			benchmarkAggregateError(b, n, AggregateLimits{MaxErrors: 100, MaxLength: 4096})
+		})
+	}
+}
@@ -0,0 +1,16 @@
+// errorsvet 检查 github.com/eachinchung/errors 的误用。
+//
+// 可以直接运行, 也可以作为 go vet 的 vettool 运行:
+//
+//	go install github.com/eachinchung/errors/analysis/cmd/errorsvet@latest
+//	errorsvet ./...
+//	go vet -vettool=$(which errorsvet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/eachinchung/errors/analysis/errorsvet"
+)
+
+func main() { singlechecker.Main(errorsvet.Analyzer) }
@@ -0,0 +1,405 @@
+// Package errorsvet 定义了检查 github.com/eachinchung/errors 误用的 Analyzer。
+//
+// Analyzer 报告以下问题:
+//
+//   - Code、Codef、WithCode 与 WithCodef 使用了本包保留的 0 ~ 100 错误码
+//   - 常量错误码从未通过 Register 或 MustRegister 注册
+//   - WithStack 包装 Wrap 等已经记录了堆栈的 error, 堆栈被重复记录
+//   - Wrap、WithStack 等包装一定为 nil 的 error, 结果总是 nil
+//   - Errorf 等格式化函数使用了不支持的 %w
+//   - Errorf、Wrapf 与 Codef 等格式化函数使用了非常量的格式字符串
+//
+// 错误码的注册情况通过 analysis.Fact 在包之间传递, 只有在当前包或其依赖的包中注册的错误码才被视为已注册。
+// 注册时的错误码需要是常量, 并且是参数中的第一个整数常量, 可以直接出现在 Register 或 MustRegister 的参数中,
+// 也可以出现在调用了 Register 或 MustRegister 的函数的参数中, 例如:
+//
+//	errors.MustRegister(coder{ErrUserNotFound, 404, "user not found"})
+//	register(ErrUserNotFound, 404, "user not found")
+package errorsvet
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const errorsPath = "github.com/eachinchung/errors"
+
+// Analyzer 检查 github.com/eachinchung/errors 的误用。
+var Analyzer = &analysis.Analyzer{
+	Name:      "errorsvet",
+	Doc:       "check for misuse of github.com/eachinchung/errors",
+	URL:       "https://pkg.go.dev/github.com/eachinchung/errors/analysis/errorsvet",
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	FactTypes: []analysis.Fact{new(registeredCodes)},
+	Run:       run,
+}
+
+// registeredCodes 是包中注册的错误码。
+type registeredCodes struct {
+	Codes []int64
+}
+
+func (*registeredCodes) AFact() {}
+
+func (f *registeredCodes) String() string {
+	return fmt.Sprintf("registered%v", f.Codes)
+}
+
+var (
+	// codeArgs 错误码参数的位置
+	codeArgs = map[string]int{"Code": 0, "Codef": 0, "WithCode": 1, "WithCodef": 1}
+
+	// formatArgs 格式字符串参数的位置
+	formatArgs = map[string]int{"Errorf": 0, "Wrapf": 1, "Codef": 1, "WithMessagef": 1, "WithCodef": 2}
+
+	// stackers 记录堆栈的函数
+	stackers = map[string]bool{
+		"New": true, "Errorf": true, "WithStack": true, "Wrap": true, "Wrapf": true,
+		"Code": true, "Codef": true, "WithCode": true, "WithCodef": true,
+	}
+
+	// nilWrappers 第一个参数为 nil 时返回 nil 的函数
+	nilWrappers = map[string]bool{
+		"WithStack": true, "Wrap": true, "Wrapf": true, "WithMessage": true, "WithMessagef": true,
+		"WithCode": true, "WithCodef": true,
+	}
+)
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	registered := collectRegistered(pass, insp)
+
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		name := errorsFunc(pass, call)
+		if name == "" {
+			return
+		}
+
+		if i, ok := codeArgs[name]; ok && i < len(call.Args) {
+			checkCode(pass, call.Args[i], registered)
+		}
+		if i, ok := formatArgs[name]; ok && i < len(call.Args) {
+			checkFormat(pass, name, call.Args[i])
+		}
+		if name == "WithStack" && len(call.Args) == 1 {
+			if inner, ok := ast.Unparen(call.Args[0]).(*ast.CallExpr); ok {
+				if innerName := errorsFunc(pass, inner); stackers[innerName] {
+					pass.ReportRangef(call, "errors.WithStack of errors.%s records the stack twice", innerName)
+				}
+			}
+		}
+	})
+
+	insp.Preorder([]ast.Node{(*ast.BlockStmt)(nil)}, func(n ast.Node) {
+		checkNilWrap(pass, n.(*ast.BlockStmt).List)
+	})
+	return nil, nil
+}
+
+// errorsFunc 返回 call 调用的 github.com/eachinchung/errors 中的函数名, 不是时返回空字符串。
+func errorsFunc(pass *analysis.Pass, call *ast.CallExpr) string {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != errorsPath {
+		return ""
+	}
+	if sig, ok := fn.Type().(*types.Signature); !ok || sig.Recv() != nil {
+		return ""
+	}
+	return fn.Name()
+}
+
+// collectRegistered 收集当前包与其依赖的包中注册的错误码, 并导出当前包的 registeredCodes。
+func collectRegistered(pass *analysis.Pass, insp *inspector.Inspector) map[int64]bool {
+	// 调用了 Register 或 MustRegister 的函数, 其参数中的常量同样视为注册的错误码
+	registerers := map[types.Object]bool{}
+	insp.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		decl := n.(*ast.FuncDecl)
+		if decl.Body == nil {
+			return
+		}
+		ast.Inspect(decl.Body, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok && isRegister(pass, call) {
+				registerers[pass.TypesInfo.Defs[decl.Name]] = true
+				return false
+			}
+			return true
+		})
+	})
+
+	var codes []int64
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		if !isRegister(pass, call) && !registerers[typeutil.Callee(pass.TypesInfo, call)] {
+			return
+		}
+		// 参数中的第一个整数常量即为错误码, 其后的通常为 HTTP 状态码
+		found := false
+		for _, arg := range call.Args {
+			ast.Inspect(arg, func(n ast.Node) bool {
+				if e, ok := n.(ast.Expr); ok && !found {
+					if v, ok := intConst(pass, e); ok {
+						codes = append(codes, v)
+						found = true
+					}
+				}
+				return !found
+			})
+		}
+	})
+
+	registered := map[int64]bool{}
+	for _, f := range pass.AllPackageFacts() {
+		if fact, ok := f.Fact.(*registeredCodes); ok {
+			for _, c := range fact.Codes {
+				registered[c] = true
+			}
+		}
+	}
+	for _, c := range codes {
+		registered[c] = true
+	}
+
+	if len(codes) > 0 {
+		sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+		pass.ExportPackageFact(&registeredCodes{Codes: codes})
+	}
+	return registered
+}
+
+func isRegister(pass *analysis.Pass, call *ast.CallExpr) bool {
+	name := errorsFunc(pass, call)
+	return name == "Register" || name == "MustRegister"
+}
+
+// intConst 返回整数常量表达式 e 的值。
+func intConst(pass *analysis.Pass, e ast.Expr) (int64, bool) {
+	tv, ok := pass.TypesInfo.Types[e]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.Int {
+		return 0, false
+	}
+	return constant.Int64Val(tv.Value)
+}
+
+// checkCode 检查错误码参数 arg 是否为保留的错误码, 或者从未注册。
+func checkCode(pass *analysis.Pass, arg ast.Expr, registered map[int64]bool) {
+	code, ok := intConst(pass, arg)
+	if !ok {
+		return
+	}
+
+	if 0 <= code && code <= 100 {
+		if !isErrorsConst(pass, arg) {
+			pass.ReportRangef(arg, "code %d is in the range 0 ~ 100 reserved by %s", code, errorsPath)
+		}
+		return
+	}
+	if !registered[code] {
+		pass.ReportRangef(arg, "code %d is never registered with errors.Register or errors.MustRegister", code)
+	}
+}
+
+// isErrorsConst 报告 e 是否为 github.com/eachinchung/errors 中定义的常量, 例如 errors.CodeInvalidArgument。
+func isErrorsConst(pass *analysis.Pass, e ast.Expr) bool {
+	var id *ast.Ident
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		id = e
+	case *ast.SelectorExpr:
+		id = e.Sel
+	default:
+		return false
+	}
+	c, ok := pass.TypesInfo.Uses[id].(*types.Const)
+	return ok && c.Pkg() != nil && c.Pkg().Path() == errorsPath
+}
+
+// checkFormat 检查格式字符串参数 arg 是否为常量, 以及是否使用了 %w。
+func checkFormat(pass *analysis.Pass, name string, arg ast.Expr) {
+	tv, ok := pass.TypesInfo.Types[arg]
+	if !ok {
+		return
+	}
+	if tv.Value == nil {
+		pass.ReportRangef(arg, "non-constant format string in call to errors.%s", name)
+		return
+	}
+	if tv.Value.Kind() == constant.String && hasVerbW(constant.StringVal(tv.Value)) {
+		pass.ReportRangef(arg, "errors.%s does not support the %%w verb, use errors.Wrap or errors.WithCode to keep the cause", name)
+	}
+}
+
+// hasVerbW 报告格式字符串 format 中是否有 %w。
+func hasVerbW(format string) bool {
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0123456789.[]*", format[i]) >= 0 {
+			i++
+		}
+		if i < len(format) && format[i] == 'w' {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNilWrap 检查 stmts 中是否包装了一定为 nil 的 error, 例如:
+//
+//	if err != nil {
+//	        return err
+//	}
+//	return errors.Wrap(err, "...")
+//
+// 以及:
+//
+//	if err == nil {
+//	        return errors.Wrap(err, "...")
+//	}
+func checkNilWrap(pass *analysis.Pass, stmts []ast.Stmt) {
+	for i, stmt := range stmts {
+		ifStmt, ok := stmt.(*ast.IfStmt)
+		if !ok {
+			continue
+		}
+		v, op := nilComparison(pass, ifStmt.Cond)
+		switch {
+		case v == nil:
+		case op == token.EQL:
+			reportNilWrap(pass, v, ifStmt.Body.List)
+		case op == token.NEQ && ifStmt.Else == nil && terminates(pass, ifStmt.Body):
+			reportNilWrap(pass, v, stmts[i+1:])
+		}
+	}
+}
+
+// nilComparison 当 cond 为 v == nil 或 v != nil 时, 返回变量 v 与比较运算符。
+func nilComparison(pass *analysis.Pass, cond ast.Expr) (*types.Var, token.Token) {
+	bin, ok := ast.Unparen(cond).(*ast.BinaryExpr)
+	if !ok || (bin.Op != token.EQL && bin.Op != token.NEQ) {
+		return nil, 0
+	}
+
+	x, y := ast.Unparen(bin.X), ast.Unparen(bin.Y)
+	if isNil(pass, x) {
+		x, y = y, x
+	}
+	if !isNil(pass, y) {
+		return nil, 0
+	}
+	id, ok := x.(*ast.Ident)
+	if !ok {
+		return nil, 0
+	}
+	v, ok := pass.TypesInfo.Uses[id].(*types.Var)
+	if !ok || !types.Identical(v.Type(), types.Universe.Lookup("error").Type()) {
+		return nil, 0
+	}
+	return v, bin.Op
+}
+
+func isNil(pass *analysis.Pass, e ast.Expr) bool {
+	id, ok := e.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, ok = pass.TypesInfo.Uses[id].(*types.Nil)
+	return ok
+}
+
+// terminates 报告 block 的最后一条语句是否一定不会继续执行后续的语句。
+func terminates(pass *analysis.Pass, block *ast.BlockStmt) bool {
+	if len(block.List) == 0 {
+		return false
+	}
+	switch s := block.List[len(block.List)-1].(type) {
+	case *ast.ReturnStmt, *ast.BranchStmt:
+		return true
+	case *ast.ExprStmt:
+		if call, ok := s.X.(*ast.CallExpr); ok {
+			if id, ok := ast.Unparen(call.Fun).(*ast.Ident); ok {
+				b, ok := pass.TypesInfo.Uses[id].(*types.Builtin)
+				return ok && b.Name() == "panic"
+			}
+		}
+	}
+	return false
+}
+
+// reportNilWrap 依次检查 stmts, 在 v 被重新赋值之前, 报告所有包装 v 的调用。
+// 循环体中重新赋值了 v 时, 下一次迭代中 v 可能不再为 nil, 因此整个循环都视为重新赋值。
+func reportNilWrap(pass *analysis.Pass, v *types.Var, stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		assigned := false
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if assigned {
+				return false
+			}
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				// 闭包可能在 v 被重新赋值之后才调用
+				return false
+			case *ast.ForStmt, *ast.RangeStmt:
+				if assigns(pass, n, v) {
+					assigned = true
+					return false
+				}
+			case *ast.AssignStmt, *ast.UnaryExpr:
+				if assigns(pass, n, v) {
+					assigned = true
+				}
+			case *ast.CallExpr:
+				name := errorsFunc(pass, n)
+				if nilWrappers[name] && len(n.Args) > 0 && usesVar(pass, n.Args[0], v) {
+					pass.ReportRangef(n, "errors.%s of %s which is always nil here returns nil", name, v.Name())
+				}
+			}
+			return true
+		})
+		if assigned {
+			return
+		}
+	}
+}
+
+// assigns 报告 node 中是否有对 v 的赋值, 包括 range 子句与取地址。
+func assigns(pass *analysis.Pass, node ast.Node, v *types.Var) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if usesVar(pass, lhs, v) {
+					found = true
+				}
+			}
+		case *ast.RangeStmt:
+			if n.Tok == token.ASSIGN && (usesVar(pass, n.Key, v) || n.Value != nil && usesVar(pass, n.Value, v)) {
+				found = true
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.AND && usesVar(pass, n.X, v) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+func usesVar(pass *analysis.Pass, e ast.Expr, v *types.Var) bool {
+	id, ok := ast.Unparen(e).(*ast.Ident)
+	return ok && pass.TypesInfo.ObjectOf(id) == v
+}
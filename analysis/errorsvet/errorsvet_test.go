@@ -0,0 +1,13 @@
+package errorsvet_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/eachinchung/errors/analysis/errorsvet"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), errorsvet.Analyzer, "a", "codes")
+}
@@ -0,0 +1,126 @@
+package a
+
+import (
+	"io"
+
+	"codes"
+
+	"github.com/eachinchung/errors"
+)
+
+func reserved() {
+	_ = errors.Code(50, "reserved")           // want `code 50 is in the range 0 ~ 100 reserved by github.com/eachinchung/errors`
+	_ = errors.WithCode(io.EOF, 1, "unknown") // want `code 1 is in the range 0 ~ 100 reserved`
+	_ = errors.Code(errors.CodeInvalidArgument, "invalid")
+}
+
+func registered() {
+	_ = errors.Code(codes.ErrUserNotFound, "not found")
+	_ = errors.Codef(codes.ErrBadRequest, "bad %s", "request")
+	_ = errors.WithCodef(io.EOF, codes.ErrWrapped, "wrapped %d", 1)
+	_ = errors.Code(2000, "unregistered") // want `code 2000 is never registered with errors.Register or errors.MustRegister`
+
+	code := 2000
+	_ = errors.Code(code, "dynamic")
+}
+
+func doubleStack() {
+	_ = errors.WithStack(errors.Wrap(io.EOF, "read")) // want `errors.WithStack of errors.Wrap records the stack twice`
+	_ = errors.WithStack((errors.New("a")))           // want `errors.WithStack of errors.New records the stack twice`
+	_ = errors.WithStack(errors.WithMessage(io.EOF, "a"))
+	_ = errors.WithStack(io.EOF)
+}
+
+func format(msg string) {
+	_ = errors.Errorf("read: %w", io.EOF)        // want `errors.Errorf does not support the %w verb`
+	_ = errors.Wrapf(io.EOF, "read %+w", io.EOF) // want `errors.Wrapf does not support the %w verb`
+	_ = errors.Errorf("100%% %s", "w")
+	_ = errors.Errorf("100%%w")
+	_ = errors.Errorf(msg)                              // want `non-constant format string in call to errors.Errorf`
+	_ = errors.Codef(codes.ErrBadRequest, msg, 1)       // want `non-constant format string in call to errors.Codef`
+	_ = errors.WithCodef(io.EOF, codes.ErrWrapped, msg) // want `non-constant format string in call to errors.WithCodef`
+	const constant = "constant %s"
+	_ = errors.Errorf(constant, "format")
+}
+
+func read() error { return nil }
+
+func nilWrap() error {
+	err := read()
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(err, "read") // want `errors.Wrap of err which is always nil here returns nil`
+}
+
+func nilWrapEqual() error {
+	err := read()
+	if err == nil {
+		return errors.WithStack(err) // want `errors.WithStack of err which is always nil here returns nil`
+	}
+	return err
+}
+
+func nilWrapReassigned() error {
+	err := read()
+	if err != nil {
+		return err
+	}
+	err = read()
+	return errors.Wrap(err, "read")
+}
+
+func nilWrapNotTerminated() error {
+	err := read()
+	if err != nil {
+		println(err)
+	}
+	return errors.Wrap(err, "read")
+}
+
+func nilWrapClosure() func() error {
+	err := read()
+	if err != nil {
+		panic(err)
+	}
+	f := func() error { return errors.Wrap(err, "later") }
+	err = read()
+	return f
+}
+
+func nilWrapReassignedInLoop(fs []func() error) error {
+	err := read()
+	if err != nil {
+		return err
+	}
+	for _, f := range fs {
+		if f == nil {
+			return errors.Wrap(err, "loop")
+		}
+		err = f()
+	}
+	return errors.Wrap(err, "done")
+}
+
+func nilWrapRangeAssigned(errs []error) error {
+	err := read()
+	if err != nil {
+		return err
+	}
+	for _, err = range errs {
+		println(errors.WithStack(err))
+	}
+	return nil
+}
+
+func nilWrapLoop(fs []func() error) error {
+	var err error
+	for _, f := range fs {
+		if err != nil {
+			break
+		}
+		_ = errors.WithMessage(err, "loop") // want `errors.WithMessage of err which is always nil here returns nil`
+		err = f()
+	}
+	return err
+}
@@ -0,0 +1,28 @@
+package codes // want package:`registered\[1001 1002 1003\]`
+
+import "github.com/eachinchung/errors"
+
+const (
+	ErrUserNotFound = 1001 + iota
+	ErrBadRequest
+	ErrWrapped
+)
+
+type coder struct {
+	code, status int
+	ext          string
+}
+
+func (c coder) Code() int       { return c.code }
+func (c coder) HTTPStatus() int { return c.status }
+func (c coder) String() string  { return c.ext }
+
+func register(code, status int, ext string) {
+	errors.MustRegister(coder{code, status, ext})
+}
+
+func init() {
+	errors.Register(coder{ErrUserNotFound, 404, "user not found"})
+	errors.MustRegister(&coder{code: ErrBadRequest, status: 400})
+	register(ErrWrapped, 500, "wrapped")
+}
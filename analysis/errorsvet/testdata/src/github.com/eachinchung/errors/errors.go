@@ -0,0 +1,28 @@
+// Package errors 是 github.com/eachinchung/errors 用于测试的桩。
+package errors
+
+const (
+	CodeUnknown         = 1
+	CodeInvalidArgument = 40
+)
+
+type Coder interface {
+	HTTPStatus() int
+	String() string
+	Code() int
+}
+
+func Register(coder Coder)     {}
+func MustRegister(coder Coder) {}
+
+func New(message string) error                                                { return nil }
+func Errorf(format string, args ...interface{}) error                         { return nil }
+func WithStack(err error) error                                               { return err }
+func Wrap(err error, message string) error                                    { return err }
+func Wrapf(err error, format string, args ...interface{}) error               { return err }
+func WithMessage(err error, message string) error                             { return err }
+func WithMessagef(err error, format string, args ...interface{}) error        { return err }
+func Code(code int, message string) error                                     { return nil }
+func Codef(code int, format string, args ...interface{}) error                { return nil }
+func WithCode(err error, code int, message string) error                      { return err }
+func WithCodef(err error, code int, format string, args ...interface{}) error { return err }
@@ -0,0 +1,74 @@
+package errors
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// CaptureMode 决定创建 error 时是否记录堆栈。
+type CaptureMode int32
+
+const (
+	// CaptureAlways 总是记录完整的堆栈, 这是默认模式。
+	CaptureAlways CaptureMode = iota
+
+	// CaptureNever 从不记录堆栈, %+v 与 withCode 的调用者输出中将不再包含堆栈信息。
+	CaptureNever
+
+	// CaptureCallSite 只记录调用点, 即一个程序计数器, 开销远小于记录完整的堆栈。
+	CaptureCallSite
+
+	// CaptureSampled 按照 CapturePolicy.Rate 的概率记录完整的堆栈, 其余只记录调用点。
+	CaptureSampled
+
+	// CaptureInternal 只为内部错误码记录完整的堆栈, 其余只记录调用点。
+	// 没有错误码的 error 视为内部错误, 内部错误码的判断见 InternalCoder。
+	CaptureInternal
+)
+
+// CapturePolicy 创建 error 时记录堆栈的策略。
+type CapturePolicy struct {
+	// Mode 记录堆栈的模式
+	Mode CaptureMode
+
+	// Rate CaptureSampled 模式下记录完整堆栈的概率, 取值范围 0 ~ 1
+	Rate float64
+}
+
+var capturePolicy atomic.Value
+
+// SetCapturePolicy 设置全局的 CapturePolicy。
+// 该策略作用于所有创建或包装 error 的函数, 例如 New、Wrap、Code 与 WithCode。
+func SetCapturePolicy(policy CapturePolicy) {
+	capturePolicy.Store(policy)
+}
+
+// GetCapturePolicy 返回当前全局的 CapturePolicy。
+func GetCapturePolicy() CapturePolicy {
+	policy, _ := capturePolicy.Load().(CapturePolicy)
+	return policy
+}
+
+// captureDepth 返回按照 CapturePolicy 与 StackMode 需要记录的堆栈深度。
+func captureDepth(code int, cause error) int {
+	policy := GetCapturePolicy()
+	switch policy.Mode {
+	case CaptureNever:
+		return 0
+	case CaptureCallSite:
+		return 1
+	case CaptureSampled:
+		if policy.Rate <= 0 || (policy.Rate < 1 && rand.Float64() >= policy.Rate) {
+			return 1
+		}
+	case CaptureInternal:
+		if !isInternal(code) {
+			return 1
+		}
+	}
+
+	if cause != nil && GetStackMode() == StackModeCompact && hasStack(cause) {
+		return 1
+	}
+	return maxStackDepth
+}
@@ -0,0 +1,157 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+const (
+	errBadRequest = iota + 2000
+	errMarkedInternal
+)
+
+// internalCoder 将 400 错误码标记为内部错误
+type internalCoder struct {
+	defaultCoder
+}
+
+func (internalCoder) Internal() bool { return true }
+
+func init() {
+	codes[errBadRequest] = defaultCoder{errBadRequest, 400, "bad request"}
+	codes[errMarkedInternal] = internalCoder{defaultCoder{errMarkedInternal, 400, "marked internal"}}
+}
+
+func withCapturePolicy(policy CapturePolicy, f func()) {
+	defer SetCapturePolicy(GetCapturePolicy())
+	SetCapturePolicy(policy)
+	f()
+}
+
+func stackDepth(err error) int {
+	return len(err.(interface{ StackTrace() StackTrace }).StackTrace())
+}
+
+func TestCapturePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy CapturePolicy
+		err    func() error
+		full   bool
+		depth  int
+	}{
+		{"always", CapturePolicy{Mode: CaptureAlways}, func() error { return New("ooh") }, true, 0},
+		{"never", CapturePolicy{Mode: CaptureNever}, func() error { return New("ooh") }, false, 0},
+		{"never wrap", CapturePolicy{Mode: CaptureNever}, func() error { return Wrap(New("ooh"), "ahh") }, false, 0},
+		{"call site", CapturePolicy{Mode: CaptureCallSite}, func() error { return Code(errEOF, "ooh") }, false, 1},
+		{"sampled 0", CapturePolicy{Mode: CaptureSampled}, func() error { return New("ooh") }, false, 1},
+		{"sampled 1", CapturePolicy{Mode: CaptureSampled, Rate: 1}, func() error { return New("ooh") }, true, 0},
+		{"internal uncoded", CapturePolicy{Mode: CaptureInternal}, func() error { return Errorf("ooh") }, true, 0},
+		{"internal 500", CapturePolicy{Mode: CaptureInternal}, func() error { return Code(errEOF, "ooh") }, true, 0},
+		{"internal 400", CapturePolicy{Mode: CaptureInternal}, func() error { return Codef(errBadRequest, "ooh") }, false, 1},
+		{"internal marked", CapturePolicy{Mode: CaptureInternal}, func() error { return Code(errMarkedInternal, "ooh") }, true, 0},
+		{"internal wrap 400", CapturePolicy{Mode: CaptureInternal}, func() error { return Wrap(Code(errBadRequest, "ooh"), "ahh") }, false, 1},
+		{"internal unregistered", CapturePolicy{Mode: CaptureInternal}, func() error { return WithCode(New("ooh"), 3000, "ahh") }, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withCapturePolicy(tt.policy, func() {
+				depth := stackDepth(tt.err())
+				if tt.full && depth <= 1 {
+					t.Errorf("want full stack, got %d frames", depth)
+				}
+				if !tt.full && depth != tt.depth {
+					t.Errorf("want %d frames, got %d", tt.depth, depth)
+				}
+			})
+		})
+	}
+}
+
+func TestCapturePolicyFormat(t *testing.T) {
+	withCapturePolicy(CapturePolicy{Mode: CaptureNever}, func() {
+		tests := []struct {
+			error
+			format string
+			want   string
+		}{
+			{New("ooh"), "%+v", "ooh"},
+			{WithStack(New("ooh")), "%+v", "ooh"},
+			{Wrap(New("ooh"), "ahh"), "%+v", "ooh\nahh"},
+			{Code(errEOF, "ooh"), "%-v", "ooh - #0 end of input"},
+			{Code(errEOF, "ooh"), "%#-v", `[{"caller":"#0","code":4,"error":"ooh","message":"end of input"}]`},
+		}
+		for i, tt := range tests {
+			if got := fmt.Sprintf(tt.format, tt.error); got != tt.want {
+				t.Errorf("test %d: %s: want %q, got %q", i+1, tt.format, tt.want, got)
+			}
+		}
+		if st := StackTraceOf(Wrap(New("ooh"), "ahh")); st != nil {
+			t.Errorf("StackTraceOf: want nil, got %v", st)
+		}
+	})
+
+	withCapturePolicy(CapturePolicy{Mode: CaptureCallSite}, func() {
+		got := fmt.Sprintf("%+v", New("ooh"))
+		want := fmt.Sprintf("ooh\n%+v", caller())
+		if got[:len(got)-2] != want[:len(want)-2] {
+			t.Errorf("%%+v: want %q, got %q", want, got)
+		}
+	})
+}
+
+func TestIsInternal(t *testing.T) {
+	tests := []struct {
+		code int
+		want bool
+	}{
+		{unknownCoder.Code(), true},
+		{errEOF, true},
+		{errBadRequest, false},
+		{errMarkedInternal, true},
+		{3000, true},
+	}
+	for _, tt := range tests {
+		if got := isInternal(tt.code); got != tt.want {
+			t.Errorf("isInternal(%d): want %v, got %v", tt.code, tt.want, got)
+		}
+	}
+}
+
+func benchmarkCapture(b *testing.B, policy CapturePolicy, f func() error) {
+	withCapturePolicy(policy, func() {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = f()
+		}
+	})
+}
+
+func BenchmarkCapture(b *testing.B) {
+	policies := []struct {
+		name   string
+		policy CapturePolicy
+	}{
+		{"always", CapturePolicy{Mode: CaptureAlways}},
+		{"never", CapturePolicy{Mode: CaptureNever}},
+		{"callsite", CapturePolicy{Mode: CaptureCallSite}},
+		{"sampled-1%", CapturePolicy{Mode: CaptureSampled, Rate: 0.01}},
+		{"internal", CapturePolicy{Mode: CaptureInternal}},
+	}
+	funcs := []struct {
+		name string
+		f    func() error
+	}{
+		{"New", func() error { return New("ooh") }},
+		{"Wrap", func() error { return Wrap(io.EOF, "ahh") }},
+		{"Code", func() error { return Code(errBadRequest, "ooh") }},
+	}
+	for _, p := range policies {
+		for _, f := range funcs {
+			b.Run(p.name+"/"+f.name, func(b *testing.B) {
+				benchmarkCapture(b, p.policy, f.f)
+			})
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// errcodes 静态扫描模块中通过 github.com/eachinchung/errors 的 Register 与 MustRegister 注册的错误码,
+// 报告被注册了多次的错误码及每一次注册的位置, 以及使用了保留的 0 ~ 100 错误码的注册。
+//
+// MustRegister 只会在运行时, 并且两个包被链接到同一个二进制文件中时才会 panic, errcodes 可以在 CI 中提前发现冲突。
+//
+// 用法:
+//
+//	errcodes [-json] [-tests] [dir]
+//
+// dir 为模块的根目录 (包含 go.mod 的目录), 默认为当前目录。
+// 与 go build 相同, 只扫描当前的 GOOS、GOARCH 与构建约束下参与构建的文件, 可以通过环境变量 GOOS 与 GOARCH 指定其他平台。
+// 使用 -json 时, 将所有错误码的注册情况以 JSON 格式输出到标准输出。
+// 发现冲突或保留的错误码时, 退出码为 1; 发生错误时, 退出码为 2。
+//
+// 注册的错误码需要是常量, 并且是参数中结构体字面量名为 C、Code 等的字段的值, 或者参数中的第一个整数常量,
+// 可以直接出现在 Register 或 MustRegister 的参数中,
+// 也可以出现在同一个包中调用了 Register 或 MustRegister 的函数的参数中, 例如:
+//
+//	errors.MustRegister(coder{ErrUserNotFound, 404, "user not found"})
+//	register(ErrUserNotFound, 404, "user not found")
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("errcodes", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	jsonOutput := flags.Bool("json", false, "print the code inventory as JSON")
+	tests := flags.Bool("tests", false, "include _test.go files")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: errcodes [-json] [-tests] [dir]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	root := "."
+	if flags.NArg() > 0 {
+		root = flags.Arg(0)
+	}
+
+	inv, err := scan(root, *tests)
+	if err != nil {
+		fmt.Fprintf(stderr, "errcodes: %v\n", err)
+		return 2
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(inv); err != nil {
+			fmt.Fprintf(stderr, "errcodes: %v\n", err)
+			return 2
+		}
+	} else {
+		printReport(stdout, inv)
+	}
+
+	if len(inv.Collisions) > 0 || len(inv.Reserved) > 0 {
+		return 1
+	}
+	return 0
+}
+
+//goland:noinspection GoUnhandledErrorResult
+func printReport(w io.Writer, inv *Inventory) {
+	for _, r := range inv.Reserved {
+		fmt.Fprintf(w, "%s: code %d%s is in the range 0 ~ 100 reserved by %s\n", r.Position, r.Code, constantName(r), errorsPath)
+	}
+	for _, c := range inv.Collisions {
+		fmt.Fprintf(w, "code %d is registered %d times:\n", c.Code, len(c.Registrations))
+		for _, r := range c.Registrations {
+			fmt.Fprintf(w, "\t%s%s\n", r.Position, constantName(r))
+			if r.Declared != nil {
+				fmt.Fprintf(w, "\t\tdeclared at %s\n", r.Declared)
+			}
+		}
+	}
+}
+
+func constantName(r Registration) string {
+	if r.Constant == "" {
+		return ""
+	}
+	return " (" + r.Constant + ")"
+}
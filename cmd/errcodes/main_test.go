@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	inv, err := scan("testdata/mod", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if inv.Module != "example.com/mod" {
+		t.Errorf("Module: want %q, got %q", "example.com/mod", inv.Module)
+	}
+
+	var got []string
+	for _, r := range inv.Registrations {
+		got = append(got, r.Position.String()+" "+strings.TrimSpace(formatCode(r)))
+	}
+	want := []string{
+		"code/code.go:29:2 1001 example.com/mod/code.ErrUserNotFound",
+		"code/code.go:30:2 1002 example.com/mod/code.ErrUserExists",
+		"code/code.go:31:2 1004 example.com/mod/code.ErrBadRequest",
+		"code/code.go:32:2 1024 example.com/mod/code.ErrShifted",
+		"user/user.go:16:2 1002 example.com/mod/code.ErrUserExists",
+		"user/user.go:17:2 1004",
+		"user/user.go:18:2 42",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Registrations:\n got %q\nwant %q", got, want)
+	}
+
+	if len(inv.Collisions) != 2 || inv.Collisions[0].Code != 1002 || inv.Collisions[1].Code != 1004 {
+		t.Errorf("Collisions: got %+v", inv.Collisions)
+	}
+	if len(inv.Reserved) != 1 || inv.Reserved[0].Code != 42 {
+		t.Errorf("Reserved: got %+v", inv.Reserved)
+	}
+	if d := inv.Registrations[0].Declared; d == nil || d.String() != "code/code.go:6:2" {
+		t.Errorf("Declared: got %v", d)
+	}
+}
+
+func formatCode(r Registration) string {
+	return strings.TrimSpace(strings.Join([]string{itoa(r.Code), r.Constant}, " "))
+}
+
+func itoa(n int64) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
+
+func TestScan_tests(t *testing.T) {
+	inv, err := scan("testdata/mod", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inv.Collisions) != 3 || inv.Collisions[2].Code != 1024 {
+		t.Errorf("Collisions: got %+v", inv.Collisions)
+	}
+}
+
+func TestScan_buildConstraints(t *testing.T) {
+	inv, err := scan("testdata/build", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inv.Registrations) != 1 || inv.Registrations[0].Code != 1100 {
+		t.Errorf("Registrations: got %+v", inv.Registrations)
+	}
+	if len(inv.Collisions) != 0 {
+		t.Errorf("Collisions: got %+v", inv.Collisions)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"testdata/mod"}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code: want 1, got %d", code)
+	}
+	want := "user/user.go:18:2: code 42 is in the range 0 ~ 100 reserved by github.com/eachinchung/errors\n" +
+		"code 1002 is registered 2 times:\n" +
+		"\tcode/code.go:30:2 (example.com/mod/code.ErrUserExists)\n" +
+		"\t\tdeclared at code/code.go:7:2\n" +
+		"\tuser/user.go:16:2 (example.com/mod/code.ErrUserExists)\n" +
+		"\t\tdeclared at code/code.go:7:2\n" +
+		"code 1004 is registered 2 times:\n" +
+		"\tcode/code.go:31:2 (example.com/mod/code.ErrBadRequest)\n" +
+		"\t\tdeclared at code/code.go:9:2\n" +
+		"\tuser/user.go:17:2\n"
+	if stdout.String() != want {
+		t.Errorf("stdout:\n got %q\nwant %q", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"-json", "testdata/mod"}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code: want 1, got %d", code)
+	}
+	var inv Inventory
+	if err := json.Unmarshal(stdout.Bytes(), &inv); err != nil {
+		t.Fatal(err)
+	}
+	if len(inv.Registrations) != 7 || len(inv.Collisions) != 2 {
+		t.Errorf("JSON inventory: got %+v", inv)
+	}
+
+	stderr.Reset()
+	if code := run([]string{"testdata/missing"}, &stdout, &stderr); code != 2 || !strings.HasPrefix(stderr.String(), "errcodes: ") {
+		t.Errorf("missing module: exit code %d, stderr %q", code, stderr.String())
+	}
+}
+
+func TestRun_clean(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"../.."}, &stdout, &stderr); code != 0 {
+		t.Errorf("exit code: want 0, got %d\n%s%s", code, stdout.String(), stderr.String())
+	}
+}
@@ -0,0 +1,494 @@
+package main
+
+import (
+	"bufio"
+	"go/ast"
+	"go/build"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const errorsPath = "github.com/eachinchung/errors"
+
+// Position 是源代码中的位置, File 为相对于模块根目录的路径。
+type Position struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+func (p Position) String() string {
+	return p.File + ":" + strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+}
+
+// Registration 是一次错误码的注册。
+type Registration struct {
+	// Code 注册的错误码
+	Code int64 `json:"code"`
+
+	// Constant 错误码常量的名称, 例如 github.com/x/y/code.ErrUserNotFound, 直接使用字面量时为空
+	Constant string `json:"constant,omitempty"`
+
+	// Declared 错误码常量的声明位置, 直接使用字面量时为 nil
+	Declared *Position `json:"declared,omitempty"`
+
+	// Package 注册所在包的导入路径
+	Package string `json:"package"`
+
+	// Position 注册的位置
+	Position Position `json:"position"`
+}
+
+// Collision 是被注册了多次的错误码。
+type Collision struct {
+	Code          int64          `json:"code"`
+	Registrations []Registration `json:"registrations"`
+}
+
+// Inventory 是模块中所有错误码的注册情况。
+type Inventory struct {
+	Module        string         `json:"module"`
+	Registrations []Registration `json:"registrations"`
+	Collisions    []Collision    `json:"collisions"`
+	Reserved      []Registration `json:"reserved"`
+}
+
+// scanner 扫描模块中的错误码注册。
+type scanner struct {
+	root   string
+	module string
+	tests  bool
+	fset   *token.FileSet
+
+	// pkgs 以导入路径为键的包
+	pkgs map[string]*pkg
+}
+
+type pkg struct {
+	path   string
+	files  []*file
+	consts map[string]*constDecl
+
+	// registerers 调用了 Register 或 MustRegister 的函数
+	registerers map[string]bool
+}
+
+type file struct {
+	ast *ast.File
+
+	// imports 以包名为键的导入路径
+	imports map[string]string
+}
+
+type constDecl struct {
+	name string
+	expr ast.Expr
+	iota int64
+	file *file
+	pos  token.Pos
+
+	value     constant.Value
+	evaluated bool
+}
+
+// scan 扫描 root 目录下的模块, tests 为 true 时包括测试文件。
+func scan(root string, tests bool) (*Inventory, error) {
+	module, err := modulePath(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &scanner{
+		root:   root,
+		module: module,
+		tests:  tests,
+		fset:   token.NewFileSet(),
+		pkgs:   map[string]*pkg{},
+	}
+	if err := s.parse(); err != nil {
+		return nil, err
+	}
+
+	inv := &Inventory{Module: module}
+	for _, p := range s.sortedPkgs() {
+		for _, r := range s.registrations(p) {
+			if 0 <= r.Code && r.Code <= 100 {
+				inv.Reserved = append(inv.Reserved, r)
+			}
+			inv.Registrations = append(inv.Registrations, r)
+		}
+	}
+	inv.Collisions = collisions(inv.Registrations)
+	return inv, nil
+}
+
+// modulePath 返回 go.mod 中的模块路径。
+func modulePath(gomod string) (string, error) {
+	f, err := os.Open(gomod)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "module") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module")), `"`), nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+	return "", &os.PathError{Op: "parse", Path: gomod, Err: os.ErrNotExist}
+}
+
+// parse 解析模块中的所有包, 跳过 vendor、testdata、以 . 或 _ 开头的目录与嵌套的模块。
+// 与 go build 相同, 按照当前的 GOOS、GOARCH 与构建约束跳过不参与构建的文件。
+func (s *scanner) parse() error {
+	return filepath.Walk(s.root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if p != s.root {
+				if name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+					return filepath.SkipDir
+				}
+				if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, ".go") || (!s.tests && strings.HasSuffix(p, "_test.go")) {
+			return nil
+		}
+		if ok, err := build.Default.MatchFile(filepath.Dir(p), info.Name()); err != nil || !ok {
+			return err
+		}
+
+		f, err := parser.ParseFile(s.fset, p, nil, 0)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.root, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		importPath := path.Join(s.module, filepath.ToSlash(rel))
+		if strings.HasSuffix(f.Name.Name, "_test") {
+			importPath += "_test"
+		}
+		s.addFile(importPath, f)
+		return nil
+	})
+}
+
+func (s *scanner) addFile(importPath string, f *ast.File) {
+	p, ok := s.pkgs[importPath]
+	if !ok {
+		p = &pkg{path: importPath, consts: map[string]*constDecl{}, registerers: map[string]bool{}}
+		s.pkgs[importPath] = p
+	}
+
+	fi := &file{ast: f, imports: map[string]string{}}
+	for _, imp := range f.Imports {
+		importPath, _ := strconv.Unquote(imp.Path.Value)
+		name := path.Base(importPath)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		fi.imports[name] = importPath
+	}
+	p.files = append(p.files, fi)
+
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			if decl.Tok == token.CONST {
+				p.addConsts(fi, decl)
+			}
+		case *ast.FuncDecl:
+			if decl.Recv == nil && decl.Body != nil && s.callsRegister(p, fi, decl.Body) {
+				p.registerers[decl.Name.Name] = true
+			}
+		}
+	}
+}
+
+// addConsts 记录 const 声明, 省略的表达式重复上一个表达式, 见 Go 语言规范。
+func (p *pkg) addConsts(f *file, decl *ast.GenDecl) {
+	var last []ast.Expr
+	for i, spec := range decl.Specs {
+		vs := spec.(*ast.ValueSpec)
+		if len(vs.Values) > 0 {
+			last = vs.Values
+		}
+		for j, name := range vs.Names {
+			if name.Name == "_" || j >= len(last) {
+				continue
+			}
+			p.consts[name.Name] = &constDecl{
+				name: name.Name,
+				expr: last[j],
+				iota: int64(i),
+				file: f,
+				pos:  name.Pos(),
+			}
+		}
+	}
+}
+
+// isRegister 报告 call 是否调用了 github.com/eachinchung/errors 的 Register 或 MustRegister。
+func (s *scanner) isRegister(p *pkg, f *file, call *ast.CallExpr) bool {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return p.path == errorsPath && (fun.Name == "Register" || fun.Name == "MustRegister")
+	case *ast.SelectorExpr:
+		x, ok := fun.X.(*ast.Ident)
+		return ok && f.imports[x.Name] == errorsPath && (fun.Sel.Name == "Register" || fun.Sel.Name == "MustRegister")
+	}
+	return false
+}
+
+func (s *scanner) callsRegister(p *pkg, f *file, body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && s.isRegister(p, f, call) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// registrations 返回包 p 中所有的错误码注册。
+// 调用 Register、MustRegister 或调用了它们的函数时, 参数中结构体字面量的错误码字段 (见 isCodeField) 的值,
+// 或者参数中的第一个整数常量, 即为注册的错误码。
+func (s *scanner) registrations(p *pkg) []Registration {
+	var regs []Registration
+	for _, f := range p.files {
+		ast.Inspect(f.ast, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			fn, isIdent := call.Fun.(*ast.Ident)
+			if !s.isRegister(p, f, call) && !(isIdent && p.registerers[fn.Name]) {
+				return true
+			}
+
+			if r, ok := s.registration(p, f, call); ok {
+				regs = append(regs, r)
+			}
+			return true
+		})
+	}
+	return regs
+}
+
+func (s *scanner) registration(p *pkg, f *file, call *ast.CallExpr) (Registration, bool) {
+	var (
+		r     Registration
+		found bool
+	)
+	// 优先使用结构体字面量中名为 C、Code 等字段的值
+	for _, arg := range call.Args {
+		ast.Inspect(arg, func(n ast.Node) bool {
+			if kv, ok := n.(*ast.KeyValueExpr); ok && !found && isCodeField(kv.Key) {
+				r, found = s.constRegistration(p, f, kv.Value)
+			}
+			return !found
+		})
+	}
+	for _, arg := range call.Args {
+		ast.Inspect(arg, func(n ast.Node) bool {
+			if found {
+				return false
+			}
+			switch n := n.(type) {
+			case *ast.KeyValueExpr:
+				// 结构体字面量的字段名不是常量
+				ast.Inspect(n.Value, func(n ast.Node) bool {
+					if e, ok := n.(ast.Expr); ok && !found {
+						r, found = s.constRegistration(p, f, e)
+					}
+					return !found
+				})
+				return false
+			case ast.Expr:
+				r, found = s.constRegistration(p, f, n)
+			}
+			return !found
+		})
+	}
+	if !found {
+		return r, false
+	}
+
+	r.Package = p.path
+	r.Position = s.position(call.Pos())
+	return r, true
+}
+
+// isCodeField 报告结构体字面量的字段名 key 是否为错误码字段, 例如 C、Code 与 ErrCode。
+func isCodeField(key ast.Expr) bool {
+	id, ok := key.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	name := strings.ToLower(id.Name)
+	return name == "c" || strings.HasSuffix(name, "code")
+}
+
+func (s *scanner) constRegistration(p *pkg, f *file, e ast.Expr) (Registration, bool) {
+	v := s.eval(p, f, e, 0)
+	if v == nil || v.Kind() != constant.Int {
+		return Registration{}, false
+	}
+	code, ok := constant.Int64Val(v)
+	if !ok {
+		return Registration{}, false
+	}
+
+	r := Registration{Code: code}
+	if c, cp := s.lookupConst(p, f, e); c != nil {
+		r.Constant = cp.path + "." + c.name
+		pos := s.position(c.pos)
+		r.Declared = &pos
+	}
+	return r, true
+}
+
+// lookupConst 当 e 为常量的名称时, 返回该常量与其所在的包。
+func (s *scanner) lookupConst(p *pkg, f *file, e ast.Expr) (*constDecl, *pkg) {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return s.lookupConst(p, f, e.X)
+	case *ast.Ident:
+		return p.consts[e.Name], p
+	case *ast.SelectorExpr:
+		x, ok := e.X.(*ast.Ident)
+		if !ok {
+			return nil, nil
+		}
+		other, ok := s.pkgs[f.imports[x.Name]]
+		if !ok {
+			return nil, nil
+		}
+		return other.consts[e.Sel.Name], other
+	}
+	return nil, nil
+}
+
+// eval 计算常量表达式 e 的值, 无法计算时返回 nil。
+func (s *scanner) eval(p *pkg, f *file, e ast.Expr, iota int64) constant.Value {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.INT || e.Kind == token.CHAR {
+			return constant.MakeFromLiteral(e.Value, e.Kind, 0)
+		}
+	case *ast.ParenExpr:
+		return s.eval(p, f, e.X, iota)
+	case *ast.Ident:
+		if e.Name == "iota" {
+			return constant.MakeInt64(iota)
+		}
+		return s.evalConst(p, f, e)
+	case *ast.SelectorExpr:
+		return s.evalConst(p, f, e)
+	case *ast.UnaryExpr:
+		x := s.eval(p, f, e.X, iota)
+		if x == nil || (e.Op != token.ADD && e.Op != token.SUB && e.Op != token.XOR) {
+			return nil
+		}
+		return constant.UnaryOp(e.Op, x, 0)
+	case *ast.BinaryExpr:
+		x, y := s.eval(p, f, e.X, iota), s.eval(p, f, e.Y, iota)
+		if x == nil || y == nil || x.Kind() != constant.Int || y.Kind() != constant.Int {
+			return nil
+		}
+		switch e.Op {
+		case token.SHL, token.SHR:
+			n, ok := constant.Uint64Val(y)
+			if !ok {
+				return nil
+			}
+			return constant.Shift(x, e.Op, uint(n))
+		case token.QUO, token.REM:
+			if constant.Sign(y) == 0 {
+				return nil
+			}
+			if e.Op == token.QUO {
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y)
+			}
+			return constant.BinaryOp(x, e.Op, y)
+		case token.ADD, token.SUB, token.MUL, token.AND, token.OR, token.XOR, token.AND_NOT:
+			return constant.BinaryOp(x, e.Op, y)
+		}
+	case *ast.CallExpr:
+		// 类型转换, 例如 int(1001) 或 Code(1001)
+		if len(e.Args) == 1 {
+			return s.eval(p, f, e.Args[0], iota)
+		}
+	}
+	return nil
+}
+
+// evalConst 计算名称为 e 的常量的值。
+func (s *scanner) evalConst(p *pkg, f *file, e ast.Expr) constant.Value {
+	c, cp := s.lookupConst(p, f, e)
+	if c == nil {
+		return nil
+	}
+	if !c.evaluated {
+		// 先标记, 避免循环引用导致无限递归
+		c.evaluated = true
+		c.value = s.eval(cp, c.file, c.expr, c.iota)
+	}
+	return c.value
+}
+
+func (s *scanner) position(pos token.Pos) Position {
+	p := s.fset.Position(pos)
+	file, err := filepath.Rel(s.root, p.Filename)
+	if err != nil {
+		file = p.Filename
+	}
+	return Position{File: filepath.ToSlash(file), Line: p.Line, Column: p.Column}
+}
+
+func (s *scanner) sortedPkgs() []*pkg {
+	pkgs := make([]*pkg, 0, len(s.pkgs))
+	for _, p := range s.pkgs {
+		pkgs = append(pkgs, p)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].path < pkgs[j].path })
+	return pkgs
+}
+
+// collisions 返回被注册了多次的错误码, 按照错误码排序。
+func collisions(regs []Registration) []Collision {
+	byCode := map[int64][]Registration{}
+	for _, r := range regs {
+		byCode[r.Code] = append(byCode[r.Code], r)
+	}
+
+	var result []Collision
+	for code, rs := range byCode {
+		if len(rs) > 1 {
+			result = append(result, Collision{Code: code, Registrations: rs})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Code < result[j].Code })
+	return result
+}
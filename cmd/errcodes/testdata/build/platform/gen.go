@@ -0,0 +1,16 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import "github.com/eachinchung/errors"
+
+type coder struct{ c int }
+
+func (c coder) Code() int       { return c.c }
+func (c coder) HTTPStatus() int { return 500 }
+func (c coder) String() string  { return "" }
+
+func main() {
+	errors.MustRegister(coder{1100})
+}
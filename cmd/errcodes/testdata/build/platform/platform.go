@@ -0,0 +1,15 @@
+package platform
+
+import "github.com/eachinchung/errors"
+
+const ErrUnsupported = 1100
+
+type coder struct{ c int }
+
+func (c coder) Code() int       { return c.c }
+func (c coder) HTTPStatus() int { return 500 }
+func (c coder) String() string  { return "" }
+
+func register(code int) {
+	errors.MustRegister(coder{code})
+}
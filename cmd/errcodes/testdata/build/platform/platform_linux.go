@@ -0,0 +1,5 @@
+package platform
+
+func init() {
+	register(ErrUnsupported)
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package platform
+
+func init() {
+	register(ErrUnsupported)
+}
@@ -0,0 +1,33 @@
+package code
+
+import "github.com/eachinchung/errors"
+
+const (
+	ErrUserNotFound = iota + 1001
+	ErrUserExists
+	_
+	ErrBadRequest
+)
+
+const ErrShifted = 1 << 10
+
+type coder struct {
+	C    int
+	HTTP int
+	Ext  string
+}
+
+func (c coder) Code() int       { return c.C }
+func (c coder) HTTPStatus() int { return c.HTTP }
+func (c coder) String() string  { return c.Ext }
+
+func register(code int, httpStatus int, message string) {
+	errors.MustRegister(&coder{C: code, HTTP: httpStatus, Ext: message})
+}
+
+func init() {
+	register(ErrUserNotFound, 404, "user not found")
+	register(ErrUserExists, 409, "user exists")
+	errors.Register(coder{HTTP: 400, C: ErrBadRequest})
+	errors.Register(coder{ErrShifted, 500, "shifted"})
+}
@@ -0,0 +1,8 @@
+package nested
+
+import "github.com/eachinchung/errors"
+
+func init() {
+	errors.Register(nil)
+	errors.Register(coder{1001})
+}
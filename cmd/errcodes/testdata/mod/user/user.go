@@ -0,0 +1,19 @@
+package user
+
+import (
+	errs "github.com/eachinchung/errors"
+
+	"example.com/mod/code"
+)
+
+type coder struct{ c int }
+
+func (c coder) Code() int       { return c.c }
+func (c coder) HTTPStatus() int { return 500 }
+func (c coder) String() string  { return "" }
+
+func init() {
+	errs.MustRegister(coder{code.ErrUserExists})
+	errs.MustRegister(coder{(code.ErrBadRequest + 1) - 1})
+	errs.Register(coder{42})
+}
@@ -0,0 +1,7 @@
+package user
+
+import "github.com/eachinchung/errors"
+
+func init() {
+	errors.Register(coder{1024})
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// callerRe 匹配 withCode 的 JSON 格式中的 caller, 例如 "#1 /path/main.go:10 (main.main)"
+var callerRe = regexp.MustCompile(`^#(\d+)(?: (.*):(\d+) \((.*)\))?$`)
+
+// node 是从日志中还原出的 error: chain、aggregate 或 *validation。
+type node interface{}
+
+// layer 是 withCode 的 JSON 格式中 error 链的一层。
+type layer struct {
+	// Error error 消息, 不带 - 与 + 时为外部 (用户) 面对的错误信息
+	Error string
+
+	// Detail 是否带有 caller、code 与 message, 即是否使用了 - 或 +
+	Detail bool
+
+	// Index 该层的编号, 从最外层的 len-1 依次递减到根因的 0
+	Index int
+
+	// File 与 Line 该层的调用位置, 没有堆栈时为空
+	File string
+	Line int
+
+	// Function 该层的调用函数
+	Function string
+
+	// Code 错误码
+	Code int
+
+	// Message 错误码对应的外部 (用户) 面对的错误信息
+	Message string
+}
+
+// chain 是一个 error 的 JSON 格式, 即 %#v、%#-v 与 %#+v 的输出。
+type chain []layer
+
+// aggregate 是 Aggregate 的 JSON 格式, 每个元素为 chain、嵌套的 aggregate 或 *validation。
+type aggregate []node
+
+// validation 是 ValidationError 的 JSON 格式。
+type validation struct {
+	Code   int
+	Error  string
+	Fields []field
+}
+
+// field 是 ValidationError 中某个字段的错误。
+type field struct {
+	Path  string
+	Error string
+
+	// Chain 字段的 error 链, 仅使用了 - 或 + 时存在
+	Chain chain
+}
+
+// findErrors 返回文本 s 中所有 error 的 JSON 格式, 按出现的顺序排列。
+// JSON 可以是整行, 也可以嵌入在文本中, 或者是结构化日志记录的字段值, 包括以字符串形式编码的 JSON。
+func findErrors(s string) []node {
+	var (
+		nodes []node
+		// end 已解码的 JSON 值的结尾, 在此之前的引号均为 JSON 字符串的开头
+		end int
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"' && i < end:
+			v, n, ok := decodeJSON(s[i:])
+			if !ok {
+				continue
+			}
+			if str, _ := v.(string); strings.ContainsAny(str, "[{") {
+				nodes = append(nodes, findErrors(str)...)
+			}
+			i += n - 1
+		case s[i] == '[' || s[i] == '{':
+			v, n, ok := decodeJSON(s[i:])
+			if !ok {
+				continue
+			}
+			if e, ok := parseNode(v); ok {
+				nodes = append(nodes, e)
+				i += n - 1
+				continue
+			}
+			// 不是 error, 继续在其字段与元素中查找
+			if i+n > end {
+				end = i + n
+			}
+		}
+	}
+	return nodes
+}
+
+// decodeJSON 解码 s 开头的 JSON 值, 返回解码的值与其长度。
+func decodeJSON(s string) (interface{}, int, bool) {
+	r := strings.NewReader(s)
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, 0, false
+	}
+	buffered, _ := io.Copy(ioutil.Discard, dec.Buffered())
+	return v, len(s) - r.Len() - int(buffered), true
+}
+
+// parseNode 将 JSON 值 v 解析为 error, v 不是 error 的 JSON 格式时返回 false。
+func parseNode(v interface{}) (node, bool) {
+	switch v := v.(type) {
+	case []interface{}:
+		if c, ok := parseChain(v); ok {
+			return c, true
+		}
+		return parseAggregate(v)
+	case map[string]interface{}:
+		return parseValidation(v)
+	}
+	return nil, false
+}
+
+func parseAggregate(v []interface{}) (aggregate, bool) {
+	if len(v) == 0 {
+		return nil, false
+	}
+
+	agg := make(aggregate, 0, len(v))
+	for _, e := range v {
+		n, ok := parseNode(e)
+		if !ok {
+			return nil, false
+		}
+		agg = append(agg, n)
+	}
+	return agg, true
+}
+
+func parseChain(v []interface{}) (chain, bool) {
+	if len(v) == 0 {
+		return nil, false
+	}
+
+	c := make(chain, 0, len(v))
+	for _, e := range v {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		l, ok := parseLayer(m)
+		if !ok {
+			return nil, false
+		}
+		c = append(c, l)
+	}
+	return c, true
+}
+
+func parseLayer(m map[string]interface{}) (layer, bool) {
+	var l layer
+	if !onlyKeys(m, "caller", "code", "error", "message") || !getString(m, "error", &l.Error) {
+		return layer{}, false
+	}
+	if len(m) == 1 {
+		return l, true
+	}
+
+	var caller string
+	if !getString(m, "caller", &caller) || !getInt(m, "code", &l.Code) || !getString(m, "message", &l.Message) {
+		return layer{}, false
+	}
+	c := callerRe.FindStringSubmatch(caller)
+	if c == nil {
+		return layer{}, false
+	}
+	l.Detail = true
+	l.Index, _ = strconv.Atoi(c[1])
+	l.File = c[2]
+	l.Line, _ = strconv.Atoi(c[3])
+	l.Function = c[4]
+	return l, true
+}
+
+func parseValidation(m map[string]interface{}) (*validation, bool) {
+	var v validation
+	fields, ok := m["fields"].([]interface{})
+	if !ok || !onlyKeys(m, "code", "error", "fields") || !getInt(m, "code", &v.Code) || !getString(m, "error", &v.Error) {
+		return nil, false
+	}
+
+	for _, e := range fields {
+		fm, ok := e.(map[string]interface{})
+		if !ok || !onlyKeys(fm, "chain", "error", "field") {
+			return nil, false
+		}
+		var f field
+		if !getString(fm, "field", &f.Path) || !getString(fm, "error", &f.Error) {
+			return nil, false
+		}
+		if c, ok := fm["chain"]; ok {
+			cv, ok := c.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			if f.Chain, ok = parseChain(cv); !ok {
+				return nil, false
+			}
+		}
+		v.Fields = append(v.Fields, f)
+	}
+	return &v, true
+}
+
+// onlyKeys 报告 m 的键是否都在 keys 中。
+func onlyKeys(m map[string]interface{}, keys ...string) bool {
+	for k := range m {
+		found := false
+		for _, key := range keys {
+			if k == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func getString(m map[string]interface{}, key string, s *string) bool {
+	v, ok := m[key].(string)
+	*s = v
+	return ok
+}
+
+func getInt(m map[string]interface{}, key string, i *int) bool {
+	n, ok := m[key].(json.Number)
+	if !ok {
+		return false
+	}
+	v, err := strconv.Atoi(n.String())
+	*i = v
+	return err == nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strconv"
+)
+
+// Definition 是错误码的定义。
+type Definition struct {
+	// Code 错误码
+	Code int `json:"code"`
+
+	// Name 错误码的名称, 例如 ErrUserNotFound
+	Name string `json:"name"`
+
+	// Status 错误码对应的 HTTP 状态码
+	Status int `json:"status,omitempty"`
+}
+
+// definitions 是以错误码为键的定义。
+type definitions map[int]Definition
+
+// loadDefinitions 读取错误码的定义文件, 文件可以是 Definition 的 JSON 数组,
+// 也可以是 errcodes -json 输出的错误码清单, 此时以错误码常量的名称作为 Name。
+func loadDefinitions(filename string) (definitions, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Definition
+	if err := json.Unmarshal(b, &list); err != nil {
+		var inv struct {
+			Registrations []struct {
+				Code     int    `json:"code"`
+				Constant string `json:"constant"`
+			} `json:"registrations"`
+		}
+		if json.Unmarshal(b, &inv) != nil || inv.Registrations == nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		for _, r := range inv.Registrations {
+			d := Definition{Code: r.Code}
+			if r.Constant != "" {
+				d.Name = path.Base(r.Constant)
+			}
+			list = append(list, d)
+		}
+	}
+
+	defs := make(definitions, len(list))
+	for _, d := range list {
+		defs[d.Code] = d
+	}
+	return defs, nil
+}
+
+// describe 返回错误码 code 及其定义, 例如 "1001 code.ErrUserNotFound, HTTP 404", 没有定义时只返回错误码。
+func (defs definitions) describe(code int) string {
+	s := strconv.Itoa(code)
+	d, ok := defs[code]
+	if !ok {
+		return s
+	}
+	if d.Name != "" {
+		s += " " + d.Name
+	}
+	if d.Status != 0 {
+		if d.Name != "" {
+			s += ","
+		}
+		s += " HTTP " + strconv.Itoa(d.Status)
+	}
+	return s
+}
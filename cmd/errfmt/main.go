@@ -0,0 +1,185 @@
+// errfmt 从日志中找出 github.com/eachinchung/errors 以 %#v、%#-v 与 %#+v 输出的 JSON,
+// 还原其中的 error 链, 并以 %+v 的格式输出, 便于阅读。
+//
+// 用法:
+//
+//	errfmt [-color auto|always|never] [-defs file] [-code codes] [file ...]
+//
+// 没有指定文件或文件为 - 时, 从标准输入读取。每行日志中的 JSON 可以是整行, 也可以嵌入在文本中,
+// 或者是结构化日志记录的字段值, 包括以字符串形式编码的 JSON, 例如:
+//
+//	[{"caller":"#0 /app/user.go:42 (main.load)","code":1001,"error":"load user","message":"user not found"}]
+//	2024/01/02 15:04:05 request failed: [{"error":"user not found"}]
+//	{"level":"error","msg":"request failed","error":"[{\"caller\":\"#0 ...\",...}]"}
+//
+// 支持 withCode、Aggregate 与 ValidationError 的 JSON 格式, 没有 error 的行将被忽略。
+// 读取多个文件时, 每个 error 之前输出其所在的文件名与行号。
+//
+// -defs 指定错误码的定义文件, 输出时在错误码之后附加其名称与 HTTP 状态码。
+// 定义文件为 {"code":1001,"name":"ErrUserNotFound","status":404} 的 JSON 数组, 或者 errcodes -json 输出的错误码清单。
+//
+// -code 只输出包含指定错误码的 error, 例如 -code 1001,2000-2999, 可以指定多次。
+// 不使用 - 与 + 输出的 JSON 中没有错误码, 指定 -code 时总是被忽略。
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var filter codeFilter
+	flags := flag.NewFlagSet("errfmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	color := flags.String("color", "auto", "colorize the output: auto, always or never")
+	defsFile := flags.String("defs", "", "code definitions file")
+	flags.Var(&filter, "code", "only print errors containing the codes, e.g. 1001,2000-2999")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: errfmt [-color auto|always|never] [-defs file] [-code codes] [file ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	p := &printer{}
+	switch *color {
+	case "always":
+		p.color = true
+	case "never":
+	case "auto":
+		p.color = isTerminal(stdout) && os.Getenv("NO_COLOR") == ""
+	default:
+		fmt.Fprintf(stderr, "errfmt: invalid -color %q\n", *color)
+		return 2
+	}
+	if *defsFile != "" {
+		defs, err := loadDefinitions(*defsFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "errfmt: %v\n", err)
+			return 2
+		}
+		p.defs = defs
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	w := bufio.NewWriter(stdout)
+	defer w.Flush()
+
+	status := 0
+	for _, name := range files {
+		if err := p.printFile(w, stdin, name, filter, len(files) > 1); err != nil {
+			fmt.Fprintf(stderr, "errfmt: %v\n", err)
+			status = 2
+		}
+	}
+	return status
+}
+
+// printFile 输出文件 name 中所有的 error, name 为 - 时读取 stdin。header 为 true 时, 在每个 error 之前输出其位置。
+func (p *printer) printFile(w io.Writer, stdin io.Reader, name string, filter codeFilter, header bool) error {
+	r := stdin
+	if name == "-" {
+		name = "<stdin>"
+	} else {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	br := bufio.NewReader(r)
+	for lineno := 1; ; lineno++ {
+		line, err := br.ReadString('\n')
+		for _, n := range findErrors(line) {
+			if !filter.match(n) {
+				continue
+			}
+			if header {
+				fmt.Fprintln(w, p.paint(colorDim, name+":"+strconv.Itoa(lineno)+":"))
+			}
+			fmt.Fprintln(w, p.format(n))
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+// isTerminal 报告 w 是否为终端。
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// codeFilter 是 -code 指定的错误码范围, 为空时匹配所有的 error。
+type codeFilter [][2]int
+
+func (f *codeFilter) String() string {
+	var s []string
+	for _, r := range *f {
+		if r[0] == r[1] {
+			s = append(s, strconv.Itoa(r[0]))
+		} else {
+			s = append(s, strconv.Itoa(r[0])+"-"+strconv.Itoa(r[1]))
+		}
+	}
+	return strings.Join(s, ",")
+}
+
+func (f *codeFilter) Set(value string) error {
+	for _, s := range strings.Split(value, ",") {
+		lo, hi := s, s
+		if i := strings.Index(s, "-"); i > 0 {
+			lo, hi = s[:i], s[i+1:]
+		}
+		min, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return fmt.Errorf("invalid code %q", s)
+		}
+		max, err := strconv.Atoi(strings.TrimSpace(hi))
+		if err != nil || max < min {
+			return fmt.Errorf("invalid code range %q", s)
+		}
+		*f = append(*f, [2]int{min, max})
+	}
+	return nil
+}
+
+// match 报告 n 是否包含 f 中的错误码。
+func (f codeFilter) match(n node) bool {
+	if len(f) == 0 {
+		return true
+	}
+	for _, code := range codes(n) {
+		for _, r := range f {
+			if code >= r[0] && code <= r[1] {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/eachinchung/errors"
+)
+
+type coder struct {
+	code   int
+	status int
+	ext    string
+}
+
+func (c coder) Code() int         { return c.code }
+func (c coder) HTTPStatus() int   { return c.status }
+func (c coder) String() string    { return c.ext }
+func (c coder) Reference() string { return "" }
+
+func init() {
+	errors.Register(coder{1001, 404, "user not found"})
+	errors.Register(coder{1002, 500, "database error"})
+}
+
+func TestFormat_roundTrip(t *testing.T) {
+	query := errors.Code(1002, "select failed")
+	load := errors.WithCode(query, 1001, "load user")
+	read := errors.WithCode(errors.Wrap(io.EOF, "read"), 1001, "read user")
+
+	v := errors.NewValidation()
+	v.Add("name", errors.Code(1001, "empty"))
+	v.Field("items").Index(0).Add("price", load)
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"chain", load},
+		{"wrapped", read},
+		{"aggregate", errors.NewAggregate(load, errors.NewAggregate(read, load), query)},
+		{"validation", v.Err()},
+		{"aggregate validation", errors.NewAggregate(v.Err(), query)},
+	}
+	for _, tt := range tests {
+		for _, flags := range []string{"", "-", "+"} {
+			data := fmt.Sprintf("%#"+flags+"v", tt.err)
+			want := fmt.Sprintf("%"+flags+"v", tt.err)
+
+			record, _ := json.Marshal(map[string]interface{}{"level": "error", "error": data})
+			lines := []string{
+				data,
+				"2024/01/02 15:04:05 request failed: " + data + " (retrying)",
+				string(record),
+				`{"level":"error","error":` + data + `}`,
+			}
+			for _, line := range lines {
+				nodes := findErrors(line)
+				if len(nodes) != 1 {
+					t.Errorf("%s %%#%sv: findErrors(%q) found %d errors", tt.name, flags, line, len(nodes))
+					continue
+				}
+				// 不使用 - 与 + 时, JSON 中只有外部 (用户) 面对的错误信息, 无法还原 %v 的输出
+				got := (&printer{}).format(nodes[0])
+				if flags != "" && got != want {
+					t.Errorf("%s %%#%sv: line %q\n got %q\nwant %q", tt.name, flags, line, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestFindErrors(t *testing.T) {
+	tests := []struct {
+		line string
+		want int
+	}{
+		{`[INFO] {"level":"info","tags":["a","b"],"count":[1,2]}`, 0},
+		{`{"error":[{"error":"missing","level":"debug"}]}`, 0},
+		{`he said "[{` + `"error":"x"}]`, 1},
+		{`[{"error":"a"}] [{"error":"b"}]`, 2},
+		{`{"a":"{\"b\":\"[{\\\"error\\\":\\\"c\\\"}]\"}"}`, 1},
+		{`[{"error":"a"}`, 0},
+	}
+	for _, tt := range tests {
+		if got := findErrors(tt.line); len(got) != tt.want {
+			t.Errorf("findErrors(%q): want %d errors, got %d", tt.line, tt.want, len(got))
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			"all",
+			[]string{"testdata/app.log"},
+			"load user - #1 [/app/user.go:42 (main.loadUser)] (1001) user not found; " +
+				"select failed - #0 [/app/db.go:17 (main.query)] (1002) database error\n" +
+				"no stock - #0 [/app/order.go:8 (main.order)] (1003) out of stock\n" +
+				"[insert failed, user not found]\n" +
+				"[0] insert failed - #0 [/app/user.go:50 (main.saveUser)] (1002) database error\n" +
+				"[1] user not found\n" +
+				"[name: empty, items[0].price: must be positive]\n" +
+				"[0] name: empty\n" +
+				"[1] items[0].price: must be positive\n",
+		},
+		{
+			"code",
+			[]string{"-code", "1003", "-code", "40-50", "testdata/app.log"},
+			"no stock - #0 [/app/order.go:8 (main.order)] (1003) out of stock\n" +
+				"[name: empty, items[0].price: must be positive]\n" +
+				"[0] name: empty\n" +
+				"[1] items[0].price: must be positive\n",
+		},
+		{
+			"defs",
+			[]string{"-defs", "testdata/defs.json", "-code", "1001", "testdata/app.log"},
+			"load user - #1 [/app/user.go:42 (main.loadUser)] (1001 ErrUserNotFound, HTTP 404) user not found; " +
+				"select failed - #0 [/app/db.go:17 (main.query)] (1002 ErrDatabase, HTTP 500) database error\n",
+		},
+		{
+			"files",
+			[]string{"-code", "1003", "testdata/app.log", "-"},
+			"testdata/app.log:3:\n" +
+				"no stock - #0 [/app/order.go:8 (main.order)] (1003) out of stock\n" +
+				"<stdin>:1:\n" +
+				"x - #0 [/app/x.go:1 (main.x)] (1003) y\n",
+		},
+		{
+			"color",
+			[]string{"-color", "always", "-code", "1003", "testdata/app.log"},
+			"\x1b[1m\x1b[31mno stock\x1b[0m\x1b[2m - #0\x1b[0m \x1b[36m[/app/order.go:8 (main.order)]\x1b[0m " +
+				"\x1b[33m(1003)\x1b[0m \x1b[32mout of stock\x1b[0m\n",
+		},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		stdin := strings.NewReader(`[{"caller":"#0 /app/x.go:1 (main.x)","code":1003,"error":"x","message":"y"}]`)
+		if code := run(tt.args, stdin, &stdout, &stderr); code != 0 {
+			t.Errorf("%s: exit code %d, stderr %q", tt.name, code, stderr.String())
+		}
+		if stdout.String() != tt.want {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, stdout.String(), tt.want)
+		}
+	}
+}
+
+func TestRun_errors(t *testing.T) {
+	tests := [][]string{
+		{"-color", "sometimes"},
+		{"-code", "2000-1000"},
+		{"-defs", "testdata/app.log"},
+		{"testdata/missing.log"},
+	}
+	for _, args := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, strings.NewReader(""), &stdout, &stderr); code != 2 || stderr.Len() == 0 {
+			t.Errorf("%q: exit code %d, stderr %q", args, code, stderr.String())
+		}
+	}
+}
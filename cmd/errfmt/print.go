@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ANSI 颜色
+const (
+	colorReset   = "\x1b[0m"
+	colorBold    = "\x1b[1m"
+	colorDim     = "\x1b[2m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+)
+
+// printer 以 %+v 格式输出还原出的 error。
+type printer struct {
+	color bool
+	defs  definitions
+}
+
+func (p *printer) paint(color, s string) string {
+	if !p.color || s == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// format 返回 n 的 %+v 格式。
+func (p *printer) format(n node) string {
+	switch n := n.(type) {
+	case chain:
+		return p.formatChain(n)
+	case aggregate:
+		texts := make([]string, len(n))
+		for i, e := range n {
+			texts[i] = p.format(e)
+		}
+		return p.formatAggregate(errorText(n), texts)
+	case *validation:
+		texts := make([]string, len(n.Fields))
+		for i, f := range n.Fields {
+			if f.Chain != nil {
+				texts[i] = f.Path + ": " + p.formatChain(f.Chain)
+			} else {
+				texts[i] = f.Path + ": " + p.paint(colorBold+colorRed, f.Error)
+			}
+		}
+		return p.formatAggregate(errorText(n), texts)
+	}
+	return ""
+}
+
+// formatChain 按照 withCode 的 %+v 格式输出 error 链, 相邻的 error 之间以 "; " 分隔。
+func (p *printer) formatChain(c chain) string {
+	var b strings.Builder
+	for i, l := range c {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(p.paint(colorBold+colorRed, l.Error))
+		if !l.Detail {
+			continue
+		}
+
+		b.WriteString(p.paint(colorDim, " - #"+strconv.Itoa(l.Index)))
+		if l.File != "" {
+			b.WriteString(" " + p.paint(colorCyan, fmt.Sprintf("[%s:%d (%s)]", l.File, l.Line, l.Function)))
+			b.WriteString(" " + p.paint(colorYellow, "("+p.defs.describe(l.Code)+")"))
+		}
+		b.WriteString(" " + p.paint(colorGreen, l.Message))
+	}
+	return b.String()
+}
+
+// formatAggregate 按照 Aggregate 的 %+v 格式, 以 header 为首行, 逐个输出 texts, 其余行缩进。
+func (p *printer) formatAggregate(header string, texts []string) string {
+	var b strings.Builder
+	b.WriteString(header)
+	for i, text := range texts {
+		fmt.Fprintf(&b, "\n%s %s", p.paint(colorMagenta, "["+strconv.Itoa(i)+"]"), strings.Replace(text, "\n", "\n    ", -1))
+	}
+	return b.String()
+}
+
+// errorText 还原 n 的 Error()。
+// 不使用 - 与 + 时, withCode 的 JSON 格式中只有外部 (用户) 面对的错误信息, 以其代替。
+func errorText(n node) string {
+	switch n := n.(type) {
+	case chain:
+		return n[0].Error
+	case aggregate:
+		if len(n) == 1 {
+			return errorText(n[0])
+		}
+		var msgs []string
+		visit(n, func(e node) {
+			msgs = append(msgs, errorText(e))
+		})
+		return joinUnique(msgs)
+	case *validation:
+		msgs := make([]string, len(n.Fields))
+		for i, f := range n.Fields {
+			msgs[i] = f.Path + ": " + f.Error
+		}
+		if len(msgs) == 1 {
+			return msgs[0]
+		}
+		return joinUnique(msgs)
+	}
+	return ""
+}
+
+// visit 按照 Aggregate 的访问顺序, 对 agg 中每一个 error 调用 f, 嵌套的 aggregate 被展开。
+func visit(agg aggregate, f func(n node)) {
+	for _, n := range agg {
+		if n, ok := n.(aggregate); ok {
+			visit(n, f)
+			continue
+		}
+		f(n)
+	}
+}
+
+// joinUnique 按照 Aggregate 的 Error() 格式, 以 "[a, b]" 的形式连接去重后的消息。
+func joinUnique(msgs []string) string {
+	var unique []string
+	seen := map[string]struct{}{}
+	for _, msg := range msgs {
+		if _, ok := seen[msg]; ok {
+			continue
+		}
+		seen[msg] = struct{}{}
+		unique = append(unique, msg)
+	}
+	return "[" + strings.Join(unique, ", ") + "]"
+}
+
+// codes 返回 n 中所有的错误码, 不使用 - 与 + 的 JSON 格式中没有错误码。
+func codes(n node) []int {
+	var cs []int
+	switch n := n.(type) {
+	case chain:
+		for _, l := range n {
+			if l.Detail {
+				cs = append(cs, l.Code)
+			}
+		}
+	case aggregate:
+		for _, e := range n {
+			cs = append(cs, codes(e)...)
+		}
+	case *validation:
+		cs = append(cs, n.Code)
+		for _, f := range n.Fields {
+			cs = append(cs, codes(f.Chain)...)
+		}
+	}
+	return cs
+}
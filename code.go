@@ -6,8 +6,26 @@ import (
 	"sync"
 )
 
+// 本包保留的错误码, 0 ~ 100 均为保留的错误码。
+const (
+	// CodeUnknown 未知错误, 未注册的错误码与没有错误码的 error 均被解析为该错误码
+	CodeUnknown = 1
+
+	// CodeMultipleErrors 多个错误, CoderMultiple 模式下, Aggregate 中 error 的错误码不同时默认的错误码
+	CodeMultipleErrors = 20
+
+	// CodeInvalidArgument 参数错误, ValidationError 默认的错误码
+	CodeInvalidArgument = 40
+
+	// CodePanic 由 panic 转换而来的错误, 见 Recover
+	CodePanic = 50
+)
+
 var (
-	unknownCoder defaultCoder = defaultCoder{1, http.StatusInternalServerError, "内部服务器错误"}
+	unknownCoder         defaultCoder = defaultCoder{CodeUnknown, http.StatusInternalServerError, "内部服务器错误"}
+	invalidArgumentCoder defaultCoder = defaultCoder{CodeInvalidArgument, http.StatusBadRequest, "参数错误"}
+	multipleErrorsCoder  defaultCoder = defaultCoder{CodeMultipleErrors, http.StatusInternalServerError, "多个错误"}
+	panicCoder           defaultCoder = defaultCoder{CodePanic, http.StatusInternalServerError, "内部服务器错误"}
 )
 
 // Coder 定义错误代码详细信息的接口。
@@ -22,6 +40,15 @@ type Coder interface {
 	Code() int
 }
 
+// InternalCoder 是 Coder 的可选接口, 用于标记错误码是否为内部错误。
+// 没有实现该接口的 Coder, HTTP 状态码大于等于 500 时视为内部错误。
+type InternalCoder interface {
+	Coder
+
+	// Internal 报告该错误码是否为内部错误
+	Internal() bool
+}
+
 type defaultCoder struct {
 	// C 错误码
 	C int
@@ -87,38 +114,48 @@ func MustRegister(coder Coder) {
 
 // ParseCoder 将任何错误解析为 *withCode。
 // nil 错误将直接返回 nil。
-// 没有 withStack 的错误, 将被解析为 ErrUnknown.
+// 以深度优先的前序遍历 error 树, 使用第一个携带错误码的 error (例如 *withCode 与 *ValidationError) 的错误码,
+// error 树中没有错误码或错误码未注册时, 将被解析为 ErrUnknown.
+// 在此之前遇到 Aggregate 等包含多个 error 的节点时, 按照全局的 CoderPolicy 从其中的 error 中得到错误码, 见 SetCoderPolicy。
 func ParseCoder(err error) Coder {
 	if err == nil {
 		return nil
 	}
-
-	if v, ok := err.(*withCode); ok {
-		if coder, ok := codes[v.code]; ok {
-			return coder
-		}
-	}
-
-	return unknownCoder
+	return parseCoder(err, GetCoderPolicy())
 }
 
-// IsCode 报告err链中的任何错误是否包含给定的错误代码。
+// IsCode 报告 err 树中的任何错误是否包含给定的错误代码。
 func IsCode(err error, code int) bool {
-	if v, ok := err.(*withCode); ok {
-		if v.code == code {
-			return true
-		}
+	return walk(err, func(err error) bool {
+		c, ok := errorCode(err)
+		return ok && c == code
+	})
+}
 
-		if v.cause != nil {
-			return IsCode(v.cause, code)
-		}
+// errorCode 返回 err 自身携带的错误码, 不检查 err 链中的其他 error。
+func errorCode(err error) (int, bool) {
+	if c, ok := err.(interface{ errorCode() (int, bool) }); ok {
+		return c.errorCode()
+	}
+	return 0, false
+}
 
-		return false
+// isInternal 报告错误码是否为内部错误, 未注册的错误码视为内部错误。
+func isInternal(code int) bool {
+	coder, ok := codes[code]
+	if !ok {
+		coder = unknownCoder
 	}
 
-	return false
+	if c, ok := coder.(InternalCoder); ok {
+		return c.Internal()
+	}
+	return coder.HTTPStatus() >= http.StatusInternalServerError
 }
 
 func init() {
 	codes[unknownCoder.Code()] = unknownCoder
+	codes[invalidArgumentCoder.Code()] = invalidArgumentCoder
+	codes[multipleErrorsCoder.Code()] = multipleErrorsCoder
+	codes[panicCoder.Code()] = panicCoder
 }
@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -50,6 +51,22 @@ func TestIsCode(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "Is code behind message",
+			args: args{
+				err:  WithMessage(Code(errEOF, "test"), "test"),
+				code: errEOF,
+			},
+			want: true,
+		},
+		{
+			name: "Is code in aggregate",
+			args: args{
+				err:  Wrap(NewAggregate(io.EOF, loadConfig()), "test"),
+				code: errEOF,
+			},
+			want: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -124,6 +141,30 @@ func TestParseCoder(t *testing.T) {
 			wantCode:     1,
 			wantNil:      false,
 		},
+		{
+			name:         "Code behind message",
+			err:          WithMessage(Code(errEOF, "test"), "test"),
+			wantHTTPCode: 500,
+			wantString:   "end of input",
+			wantCode:     errEOF,
+			wantNil:      false,
+		},
+		{
+			name:         "Code in aggregate",
+			err:          NewAggregate(io.EOF, loadConfig()),
+			wantHTTPCode: 500,
+			wantString:   "configuration not valid error",
+			wantCode:     errConfigurationNotValid,
+			wantNil:      false,
+		},
+		{
+			name:         "Unregistered code",
+			err:          WithCode(Code(errEOF, "test"), 3000, "test"),
+			wantHTTPCode: 500,
+			wantString:   "内部服务器错误",
+			wantCode:     1,
+			wantNil:      false,
+		},
 		{
 			name:    "wantNil",
 			wantNil: true,
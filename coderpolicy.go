@@ -0,0 +1,155 @@
+package errors
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// CoderMode 决定如何从包含多个 error 的节点 (例如 Aggregate) 中得到唯一的 Coder。
+type CoderMode int32
+
+const (
+	// CoderFirst 使用第一个携带错误码的 error 的 Coder, 这是默认模式。
+	CoderFirst CoderMode = iota
+
+	// CoderMostSevere 使用 HTTP 状态码最大的 Coder, 相同时使用第一个。
+	CoderMostSevere
+
+	// CoderMajority 使用出现次数最多的 Coder, 相同时使用第一个出现的。
+	CoderMajority
+
+	// CoderMultiple 所有 error 的错误码相同时使用该 Coder, 否则使用 CoderPolicy.Multiple。
+	CoderMultiple
+)
+
+// CoderPolicy 从包含多个 error 的节点中得到唯一的 Coder 的策略。
+type CoderPolicy struct {
+	// Mode 得到 Coder 的模式
+	Mode CoderMode
+
+	// Multiple CoderMultiple 模式下, error 的错误码不同时使用的 Coder。
+	// 为 nil 时, 使用错误码为 CodeMultipleErrors 的 Coder, 其 HTTP 状态码为其中最大的 4xx 或 5xx 状态码, 没有时为 500。
+	// 不使用 207 Multi-Status, 以免失败的批量请求得到 2xx 的响应
+	Multiple Coder
+}
+
+var coderPolicy atomic.Value
+
+// SetCoderPolicy 设置全局的 CoderPolicy, 该策略作用于 ParseCoder。
+func SetCoderPolicy(policy CoderPolicy) {
+	coderPolicy.Store(policy)
+}
+
+// GetCoderPolicy 返回当前全局的 CoderPolicy。
+func GetCoderPolicy() CoderPolicy {
+	policy, _ := coderPolicy.Load().(CoderPolicy)
+	return policy
+}
+
+// ParseCoderWith 与 ParseCoder 相同, 但使用 policy 而不是全局的 CoderPolicy。
+// nil 错误将直接返回 nil。
+func ParseCoderWith(err error, policy CoderPolicy) Coder {
+	if err == nil {
+		return nil
+	}
+	return parseCoder(err, policy)
+}
+
+// MemberCoders 返回 err 中每一个 error 的 Coder, 可用于在响应中列出每一个 error 的错误码。
+// err 为包含多个 error 的节点时, 递归展开其中 (包括嵌套的 Aggregate) 的每一个 error;
+// 否则结果只包含 ParseCoder(err)。
+// nil 错误将直接返回 nil。
+func MemberCoders(err error) []Coder {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := errorCode(err); ok || !isMulti(err) {
+		return []Coder{ParseCoder(err)}
+	}
+
+	var coders []Coder
+	for _, child := range children(err) {
+		coders = append(coders, MemberCoders(child)...)
+	}
+	return coders
+}
+
+// parseCoder 以深度优先的前序遍历 error 树, 使用第一个携带错误码的 error 的 Coder;
+// 在此之前遇到包含多个 error 的节点时, 按照 policy 从其中的 error 中得到 Coder。
+func parseCoder(err error, policy CoderPolicy) Coder {
+	var coder Coder = unknownCoder
+	walk(err, func(err error) bool {
+		if code, ok := errorCode(err); ok {
+			if c, ok := codes[code]; ok {
+				coder = c
+			}
+			return true
+		}
+		if policy.Mode != CoderFirst && isMulti(err) {
+			coder = policy.resolve(err)
+			return true
+		}
+		return false
+	})
+	return coder
+}
+
+// resolve 按照 policy 从包含多个 error 的节点 err 中得到唯一的 Coder。
+func (policy CoderPolicy) resolve(err error) Coder {
+	var coders []Coder
+	for _, child := range children(err) {
+		if child != nil {
+			coders = append(coders, parseCoder(child, policy))
+		}
+	}
+	if len(coders) == 0 {
+		return unknownCoder
+	}
+
+	coder := coders[0]
+	switch policy.Mode {
+	case CoderMostSevere:
+		for _, c := range coders[1:] {
+			if c.HTTPStatus() > coder.HTTPStatus() {
+				coder = c
+			}
+		}
+	case CoderMajority:
+		counts := map[int]int{}
+		for _, c := range coders {
+			counts[c.Code()]++
+		}
+		for _, c := range coders[1:] {
+			if counts[c.Code()] > counts[coder.Code()] {
+				coder = c
+			}
+		}
+	case CoderMultiple:
+		for _, c := range coders[1:] {
+			if c.Code() == coder.Code() {
+				continue
+			}
+			if policy.Multiple != nil {
+				return policy.Multiple
+			}
+			return multipleCoder(coders)
+		}
+	}
+	return coder
+}
+
+// multipleCoder 返回错误码为 CodeMultipleErrors 的 Coder, 其 HTTP 状态码为 coders 中最大的 4xx 或 5xx 状态码, 没有时为 500。
+func multipleCoder(coders []Coder) Coder {
+	coder := multipleErrorsCoder
+	status := 0
+	for _, c := range coders {
+		if s := c.HTTPStatus(); s >= http.StatusBadRequest && s > status {
+			status = s
+		}
+	}
+	if status != 0 {
+		coder.HTTP = status
+	}
+	return coder
+}
@@ -0,0 +1,79 @@
+package errors
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const errNotFound = 2100
+
+func init() {
+	codes[errNotFound] = defaultCoder{errNotFound, http.StatusNotFound, "not found"}
+}
+
+func TestParseCoderWith(t *testing.T) {
+	notFound := func() error { return Code(errNotFound, "not found") }
+	badRequest := func() error { return Code(errBadRequest, "bad request") }
+	multiple := defaultCoder{3100, http.StatusConflict, "conflict"}
+
+	tests := []struct {
+		name   string
+		policy CoderPolicy
+		err    error
+		want   int
+	}{
+		{"first", CoderPolicy{}, NewAggregate(io.EOF, notFound(), badRequest()), errNotFound},
+		{"first no code", CoderPolicy{}, NewAggregate(io.EOF), unknownCoder.Code()},
+		{"most severe", CoderPolicy{Mode: CoderMostSevere}, NewAggregate(badRequest(), notFound()), errNotFound},
+		{"most severe unknown", CoderPolicy{Mode: CoderMostSevere}, NewAggregate(notFound(), io.EOF), unknownCoder.Code()},
+		{"most severe nested", CoderPolicy{Mode: CoderMostSevere},
+			NewAggregate(badRequest(), Wrap(NewAggregate(badRequest(), notFound()), "batch")), errNotFound},
+		{"majority", CoderPolicy{Mode: CoderMajority}, NewAggregate(badRequest(), notFound(), notFound()), errNotFound},
+		{"majority tie", CoderPolicy{Mode: CoderMajority},
+			NewAggregate(badRequest(), notFound(), notFound(), badRequest()), errBadRequest},
+		{"multiple same", CoderPolicy{Mode: CoderMultiple}, NewAggregate(notFound(), notFound()), errNotFound},
+		{"multiple", CoderPolicy{Mode: CoderMultiple}, NewAggregate(notFound(), badRequest()), CodeMultipleErrors},
+		{"multiple custom", CoderPolicy{Mode: CoderMultiple, Multiple: multiple},
+			NewAggregate(notFound(), badRequest()), multiple.Code()},
+		{"coded wrapper wins", CoderPolicy{Mode: CoderMostSevere},
+			WithCode(NewAggregate(badRequest(), io.EOF), errNotFound, "wrap"), errNotFound},
+		{"not aggregate", CoderPolicy{Mode: CoderMajority}, Wrap(badRequest(), "wrap"), errBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseCoderWith(tt.err, tt.policy).Code())
+		})
+	}
+	assert.Nil(t, ParseCoderWith(nil, CoderPolicy{}))
+}
+
+func TestSetCoderPolicy(t *testing.T) {
+	defer SetCoderPolicy(GetCoderPolicy())
+
+	err := NewAggregate(Code(errBadRequest, "a"), Code(errNotFound, "b"))
+	assert.Equal(t, errBadRequest, ParseCoder(err).Code())
+
+	SetCoderPolicy(CoderPolicy{Mode: CoderMultiple})
+	coder := ParseCoder(err)
+	assert.Equal(t, CodeMultipleErrors, coder.Code())
+	assert.Equal(t, http.StatusNotFound, coder.HTTPStatus())
+
+	ok := multipleCoder([]Coder{defaultCoder{3200, http.StatusOK, ""}, defaultCoder{3201, http.StatusAccepted, ""}})
+	assert.Equal(t, http.StatusInternalServerError, ok.HTTPStatus())
+}
+
+func TestMemberCoders(t *testing.T) {
+	assert.Nil(t, MemberCoders(nil))
+
+	err := NewAggregate(Code(errBadRequest, "a"), NewAggregate(io.EOF, Code(errNotFound, "b")))
+	var got []int
+	for _, c := range MemberCoders(err) {
+		got = append(got, c.Code())
+	}
+	assert.Equal(t, []int{errBadRequest, unknownCoder.Code(), errNotFound}, got)
+
+	assert.Len(t, MemberCoders(Wrap(err, "wrap")), 1)
+}
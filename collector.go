@@ -0,0 +1,129 @@
+//go:build go1.13
+// +build go1.13
+
+package errors
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Collector 用于逐步收集 error, 最终生成 Aggregate, 可以安全地被多个 goroutine 同时使用。
+// Collector 的零值可以直接使用, 此时没有容量限制, 也不会去重。
+type Collector struct {
+	mu         sync.Mutex
+	errs       []error
+	limit      int
+	dropped    int
+	dedup      func(error) string
+	seen       map[string]struct{}
+	duplicates int
+}
+
+// NewCollector 返回一个新的 Collector, limit 大于 0 时, 最多收集 limit 个 error。
+func NewCollector(limit int) *Collector {
+	c := &Collector{}
+	c.SetLimit(limit)
+	return c
+}
+
+// SetLimit 设置最多收集的 error 数量, 超出的 error 将被丢弃并计入 Dropped。
+// n 小于等于 0 时不限制。
+func (c *Collector) SetLimit(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n < 0 {
+		n = 0
+	}
+	c.limit = n
+}
+
+// Dedup 启用去重, key 相同的 error 只收集第一个, 其余的计入 Duplicates。
+// key 为 nil 时, 按照 Error() 去重。
+func (c *Collector) Dedup(key func(error) string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key == nil {
+		key = func(err error) string { return err.Error() }
+	}
+	c.dedup = key
+	c.seen = map[string]struct{}{}
+	for _, err := range c.errs {
+		c.seen[key(err)] = struct{}{}
+	}
+}
+
+// Add 收集 errs 中所有非 nil 的 error。
+func (c *Collector) Add(errs ...error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, err := range errs {
+		if err != nil {
+			c.add(err)
+		}
+	}
+}
+
+// Addf 根据格式说明符格式化, 收集以该字符串为值的 error, 与 Errorf 相同, 在它被调用的地方记录堆栈跟踪。
+func (c *Collector) Addf(format string, args ...interface{}) {
+	err := &fundamental{
+		msg:   fmt.Sprintf(format, args...),
+		stack: capture(unknownCoder.Code(), nil),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(err)
+}
+
+func (c *Collector) add(err error) {
+	if c.dedup != nil {
+		key := c.dedup(err)
+		if _, ok := c.seen[key]; ok {
+			c.duplicates++
+			return
+		}
+		c.seen[key] = struct{}{}
+	}
+
+	if c.limit > 0 && len(c.errs) >= c.limit {
+		c.dropped++
+		return
+	}
+	c.errs = append(c.errs, err)
+}
+
+// Len 返回已收集的 error 数量。
+func (c *Collector) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.errs)
+}
+
+// Dropped 返回因超出容量限制而被丢弃的 error 数量。
+func (c *Collector) Dropped() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dropped
+}
+
+// Duplicates 返回因去重而被忽略的 error 数量。
+func (c *Collector) Duplicates() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.duplicates
+}
+
+// Result 将已收集的 error 转换为 Aggregate, 没有收集到任何 error 时返回 nil。
+// 之后继续收集的 error 不会影响已返回的 Aggregate。
+func (c *Collector) Result() Aggregate {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	errs := make([]error, len(c.errs))
+	copy(errs, c.errs)
+	return NewAggregate(errs...)
+}
@@ -0,0 +1,77 @@
+//go:build go1.13
+// +build go1.13
+
+package errors
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	var c Collector
+	assert.Nil(t, c.Result())
+
+	c.Add(nil, io.EOF)
+	c.Add()
+	c.Addf("row %d: %s", 3, "invalid")
+	assert.Equal(t, 2, c.Len())
+
+	agg := c.Result()
+	assert.Equal(t, "[EOF, row 3: invalid]", agg.Error())
+	assert.Equal(t, "github.com/eachinchung/errors.TestCollector", StackTraceOf(agg.Errors()[1])[0].name())
+
+	c.Add(io.ErrUnexpectedEOF)
+	assert.Len(t, agg.Errors(), 2, "Result must not be affected by later Add")
+	assert.Len(t, c.Result().Errors(), 3)
+}
+
+func TestCollector_limit(t *testing.T) {
+	c := NewCollector(2)
+	c.Add(io.EOF, io.ErrUnexpectedEOF, io.ErrClosedPipe)
+	c.Addf("ignored")
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, 2, c.Dropped())
+	assert.Equal(t, []error{io.EOF, io.ErrUnexpectedEOF}, c.Result().Errors())
+
+	c.SetLimit(-1)
+	c.Add(io.ErrClosedPipe)
+	assert.Equal(t, 3, c.Len())
+}
+
+func TestCollector_dedup(t *testing.T) {
+	var c Collector
+	c.Add(io.EOF)
+	c.Dedup(nil)
+	c.Add(io.EOF, New("EOF"), io.ErrUnexpectedEOF)
+	assert.Equal(t, []error{io.EOF, io.ErrUnexpectedEOF}, c.Result().Errors())
+	assert.Equal(t, 2, c.Duplicates())
+
+	codes := NewCollector(0)
+	codes.Dedup(func(err error) string { return fmt.Sprint(ParseCoder(err).Code()) })
+	codes.Add(Code(errEOF, "a"), Code(errEOF, "b"), Code(errInvalidJSON, "c"))
+	assert.Equal(t, "[a, c]", codes.Result().Error())
+}
+
+func TestCollector_concurrent(t *testing.T) {
+	c := NewCollector(50)
+	c.Dedup(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.Addf("err-%d", i%80)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 50, c.Len())
+	assert.Equal(t, 30, c.Dropped())
+	assert.Equal(t, 20, c.Duplicates())
+}
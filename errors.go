@@ -2,7 +2,6 @@ package errors
 
 //goland:noinspection SpellCheckingInspection
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,7 +13,7 @@ import (
 func New(message string) error {
 	return &fundamental{
 		msg:   message,
-		stack: callers(),
+		stack: capture(unknownCoder.Code(), nil),
 	}
 }
 
@@ -23,7 +22,7 @@ func New(message string) error {
 func Errorf(format string, args ...interface{}) error {
 	return &fundamental{
 		msg:   fmt.Sprintf(format, args...),
-		stack: callers(),
+		stack: capture(unknownCoder.Code(), nil),
 	}
 }
 
@@ -39,11 +38,11 @@ func WithStack(err error) error {
 			msg:   e.msg,
 			code:  e.code,
 			cause: err,
-			stack: callers(),
+			stack: capture(e.code, err),
 		}
 	}
 
-	return &withStack{err, callers()}
+	return &withStack{err, capture(unknownCoder.Code(), err)}
 }
 
 // Wrap 返回 error, 该错误用 Wrap 堆栈跟踪注释 err, 并返回提供错误信息
@@ -58,12 +57,12 @@ func Wrap(err error, message string) error {
 			msg:   message,
 			code:  e.code,
 			cause: err,
-			stack: callers(),
+			stack: capture(e.code, err),
 		}
 	}
 
 	err = &withMessage{cause: err, msg: message}
-	return &withStack{err, callers()}
+	return &withStack{err, capture(unknownCoder.Code(), err)}
 }
 
 // Wrapf 返回 error, 该错误用 Wrapf 堆栈跟踪注释 err, 并返回格式化错误信息
@@ -78,7 +77,7 @@ func Wrapf(err error, format string, args ...interface{}) error {
 			msg:   fmt.Sprintf(format, args...),
 			code:  e.code,
 			cause: err,
-			stack: callers(),
+			stack: capture(e.code, err),
 		}
 	}
 
@@ -88,7 +87,7 @@ func Wrapf(err error, format string, args ...interface{}) error {
 	}
 	return &withStack{
 		err,
-		callers(),
+		capture(unknownCoder.Code(), err),
 	}
 }
 
@@ -121,7 +120,7 @@ func Code(code int, message string) error {
 	return &withCode{
 		msg:   message,
 		code:  code,
-		stack: callers(),
+		stack: capture(code, nil),
 	}
 }
 
@@ -130,7 +129,7 @@ func Codef(code int, format string, args ...interface{}) error {
 	return &withCode{
 		msg:   fmt.Sprintf(format, args...),
 		code:  code,
-		stack: callers(),
+		stack: capture(code, nil),
 	}
 }
 
@@ -146,7 +145,7 @@ func WithCode(err error, code int, message string) error {
 		msg:   message,
 		code:  code,
 		cause: err,
-		stack: callers(),
+		stack: capture(code, err),
 	}
 }
 
@@ -162,7 +161,7 @@ func WithCodef(err error, code int, format string, args ...interface{}) error {
 		msg:   fmt.Sprintf(format, args...),
 		code:  code,
 		cause: err,
-		stack: callers(),
+		stack: capture(code, err),
 	}
 }
 
@@ -209,7 +208,7 @@ func (w *withStack) Format(s fmt.State, verb rune) {
 	case 'v':
 		if s.Flag('+') {
 			fmt.Fprintf(s, "%+v", w.Cause())
-			w.stack.Format(s, verb)
+			w.stack.formatWrap(s, verb, w.Cause())
 			return
 		}
 		fallthrough
@@ -262,6 +261,8 @@ func (w *withCode) Cause() error { return w.cause }
 // Unwrap 提供 Go 1.13 兼容性
 func (w *withCode) Unwrap() error { return w.cause }
 
+func (w *withCode) errorCode() (int, bool) { return w.code, true }
+
 // Format implements fmt.Formatter. https://golang.org/pkg/fmt/#hdr-Printing
 //
 // Verbs:
@@ -276,9 +277,6 @@ func (w *withCode) Unwrap() error { return w.cause }
 func (w *withCode) Format(state fmt.State, verb rune) {
 	switch verb {
 	case 'v':
-		str := bytes.NewBuffer([]byte{})
-		var jsonData []map[string]interface{}
-
 		var (
 			flagDetail bool
 			flagTrace  bool
@@ -296,18 +294,7 @@ func (w *withCode) Format(state fmt.State, verb rune) {
 			flagTrace = true
 		}
 
-		sep := ""
-		errs := list(w)
-		length := len(errs)
-		for k, e := range errs {
-			info := buildFormatInfo(e)
-			jsonData, str = format(length-k-1, jsonData, str, info, sep, flagDetail, flagTrace, modeJSON)
-			sep = "; "
-
-			if !flagTrace {
-				break
-			}
-		}
+		jsonData, str := formatChain(w, flagDetail, flagTrace, modeJSON)
 		if modeJSON {
 			var b []byte
 			b, _ = json.Marshal(jsonData)
@@ -330,6 +317,9 @@ func (w *withCode) Format(state fmt.State, verb rune) {
 //
 // 如果 error 没有实现 Cause, 则返回原始 error。
 // 如果 error 为 nil, 则将返回 nil, 而无需进一步调查。
+//
+// Aggregate 与标准库 errors.Join 的结果等包含多个 error 的节点没有单一的原因, Cause 将在此停止并返回该节点,
+// 需要每个分支的根本原因时, 应当使用 Causes。
 func Cause(err error) error {
 	type causer interface {
 		Cause() error
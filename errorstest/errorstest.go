@@ -0,0 +1,140 @@
+// Package errorstest 提供测试 github.com/eachinchung/errors 的 error 的断言函数。
+//
+// 断言函数可以直接使用 *testing.T 与 *testing.B, 失败时将输出 error 以 %+v 格式化的完整错误链。
+// Normalize 与 AssertGolden 用于对 error 的格式化输出进行快照测试。
+package errorstest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eachinchung/errors"
+)
+
+// TB 是断言函数需要的 testing.TB 的子集, *testing.T 与 *testing.B 均实现了该接口。
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertCode 断言 err 树中有 error 包含错误码 code, 见 errors.IsCode。
+func AssertCode(t TB, err error, code int) bool {
+	t.Helper()
+	if errors.IsCode(err, code) {
+		return true
+	}
+	return fail(t, err, "want code %d, got %s", code, coderString(err))
+}
+
+// AssertHTTPStatus 断言 errors.ParseCoder 解析 err 得到的 HTTP 状态码为 status。
+func AssertHTTPStatus(t TB, err error, status int) bool {
+	t.Helper()
+	if err != nil && errors.ParseCoder(err).HTTPStatus() == status {
+		return true
+	}
+	return fail(t, err, "want HTTP status %d, got %s", status, coderString(err))
+}
+
+// AssertChain 断言 err 链中每一层的消息依次为 messages, 从最外层的包装到根因。
+// 每一层的消息不包括其原因的消息, 例如 errors.Wrap(io.EOF, "read") 的消息为 "read" 与 "EOF";
+// 只记录堆栈而没有消息的层 (例如 errors.WithStack) 将被跳过。
+// 遇到 Aggregate 等包含多个 error 的节点时, 该节点的消息即为链中最后一层的消息。
+func AssertChain(t TB, err error, messages ...string) bool {
+	t.Helper()
+	got := Chain(err)
+	if equal(got, messages) {
+		return true
+	}
+	return fail(t, err, "want chain %q, got %q", messages, got)
+}
+
+// AssertIs 断言 err 链中有 error 与 target 相同, 见 errors.Is。
+func AssertIs(t TB, err, target error) bool {
+	t.Helper()
+	if errors.Is(err, target) {
+		return true
+	}
+	return fail(t, err, "want %v in error chain", target)
+}
+
+// AssertHasStack 断言 err 链中有 error 记录了堆栈, 见 errors.StackTraceOf。
+func AssertHasStack(t TB, err error) bool {
+	t.Helper()
+	if errors.StackTraceOf(err) != nil {
+		return true
+	}
+	return fail(t, err, "want stack trace in error chain")
+}
+
+// AssertAggregateContains 断言 err 为 Aggregate, 并且其中 (包括嵌套的 Aggregate) 有 error 与 target 相同。
+func AssertAggregateContains(t TB, err, target error) bool {
+	t.Helper()
+	if _, ok := err.(errors.Aggregate); !ok {
+		return fail(t, err, "want Aggregate, got %T", err)
+	}
+	if errors.Find(err, errors.MatchIs(target)) != nil {
+		return true
+	}
+	return fail(t, err, "want %v in Aggregate", target)
+}
+
+// Chain 返回 err 链中每一层的消息, 规则见 AssertChain。
+func Chain(err error) []string {
+	var messages []string
+	for err != nil {
+		msg := err.Error()
+		cause := next(err)
+		if cause != nil {
+			if _, ok := err.(errors.Aggregate); !ok {
+				msg = strings.TrimSuffix(strings.TrimSuffix(msg, cause.Error()), ": ")
+			}
+		}
+		if msg != "" || cause == nil {
+			messages = append(messages, msg)
+		}
+		err = cause
+	}
+	return messages
+}
+
+// next 返回 err 链中的下一个 error, 优先使用 Unwrap, 其次使用 Cause。
+func next(err error) error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	case interface{ Cause() error }:
+		return e.Cause()
+	}
+	return nil
+}
+
+func coderString(err error) string {
+	if err == nil {
+		return "nil error"
+	}
+	coder := errors.ParseCoder(err)
+	return fmt.Sprintf("code %d (HTTP %d)", coder.Code(), coder.HTTPStatus())
+}
+
+// fail 报告断言失败, 并输出 err 以 %+v 格式化的完整错误链。
+func fail(t TB, err error, format string, args ...interface{}) bool {
+	t.Helper()
+	chain := "<nil>"
+	if err != nil {
+		chain = strings.Replace(fmt.Sprintf("%+v", err), "\n", "\n\t", -1)
+	}
+	t.Errorf(format+"\nerror:\n\t%s", append(args, chain)...)
+	return false
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package errorstest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/eachinchung/errors"
+)
+
+const errNotFound = 1404
+
+func init() {
+	errors.Register(coder{errNotFound, 404, "not found"})
+}
+
+type coder struct {
+	code, status int
+	ext          string
+}
+
+func (c coder) Code() int       { return c.code }
+func (c coder) HTTPStatus() int { return c.status }
+func (c coder) String() string  { return c.ext }
+
+// recorder 记录断言失败的输出
+type recorder struct {
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertions(t *testing.T) {
+	notFound := errors.WithCode(io.EOF, errNotFound, "user not found")
+	agg := errors.NewAggregate(errors.New("a"), errors.NewAggregate(io.ErrUnexpectedEOF))
+
+	tests := []struct {
+		name   string
+		assert func(t TB) bool
+		want   bool
+	}{
+		{"AssertCode", func(t TB) bool { return AssertCode(t, errors.Wrap(notFound, "get"), errNotFound) }, true},
+		{"AssertCode fail", func(t TB) bool { return AssertCode(t, io.EOF, errNotFound) }, false},
+		{"AssertHTTPStatus", func(t TB) bool { return AssertHTTPStatus(t, notFound, 404) }, true},
+		{"AssertHTTPStatus fail", func(t TB) bool { return AssertHTTPStatus(t, io.EOF, 404) }, false},
+		{"AssertHTTPStatus nil", func(t TB) bool { return AssertHTTPStatus(t, nil, 500) }, false},
+		{"AssertChain", func(t TB) bool {
+			return AssertChain(t, errors.WithMessage(errors.Wrap(notFound, "get"), "handler"), "handler", "get", "user not found", "EOF")
+		}, true},
+		{"AssertChain fail", func(t TB) bool { return AssertChain(t, errors.Wrap(io.EOF, "read"), "read") }, false},
+		{"AssertChain aggregate", func(t TB) bool { return AssertChain(t, errors.Wrap(agg, "batch"), "batch", "[a, unexpected EOF]") }, true},
+		{"AssertIs", func(t TB) bool { return AssertIs(t, notFound, io.EOF) }, true},
+		{"AssertIs fail", func(t TB) bool { return AssertIs(t, notFound, io.ErrUnexpectedEOF) }, false},
+		{"AssertHasStack", func(t TB) bool { return AssertHasStack(t, errors.WithStack(io.EOF)) }, true},
+		{"AssertHasStack fail", func(t TB) bool { return AssertHasStack(t, io.EOF) }, false},
+		{"AssertAggregateContains", func(t TB) bool { return AssertAggregateContains(t, agg, io.ErrUnexpectedEOF) }, true},
+		{"AssertAggregateContains fail", func(t TB) bool { return AssertAggregateContains(t, agg, io.EOF) }, false},
+		{"AssertAggregateContains not aggregate", func(t TB) bool { return AssertAggregateContains(t, io.EOF, io.EOF) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			if got := tt.assert(r); got != tt.want {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+			if tt.want != (len(r.failures) == 0) {
+				t.Errorf("failures: %q", r.failures)
+			}
+		})
+	}
+}
+
+func TestFailureOutput(t *testing.T) {
+	r := &recorder{}
+	AssertCode(r, errors.Wrap(io.EOF, "read"), errNotFound)
+	if len(r.failures) != 1 {
+		t.Fatalf("want 1 failure, got %d", len(r.failures))
+	}
+
+	got := r.failures[0]
+	for _, want := range []string{
+		"want code 1404, got code 1 (HTTP 500)\nerror:\n\tEOF\n\tread\n",
+		"\tgithub.com/eachinchung/errors/errorstest.TestFailureOutput\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("failure output: want %q in\n%s", want, got)
+		}
+	}
+
+	r = &recorder{}
+	AssertIs(r, nil, io.EOF)
+	if want := "want EOF in error chain\nerror:\n\t<nil>"; r.failures[0] != want {
+		t.Errorf("failure output: want %q, got %q", want, r.failures[0])
+	}
+}
+
+func TestChain(t *testing.T) {
+	tests := []struct {
+		err  error
+		want []string
+	}{
+		{nil, nil},
+		{io.EOF, []string{"EOF"}},
+		{errors.WithStack(errors.New("a")), []string{"a"}},
+		{errors.Wrapf(errors.Code(errNotFound, "b"), "c %d", 1), []string{"c 1", "b"}},
+	}
+	for _, tt := range tests {
+		if got := Chain(tt.err); !equal(got, tt.want) {
+			t.Errorf("Chain(%v): want %q, got %q", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestWithTesting(t *testing.T) {
+	AssertChain(t, errors.Wrap(io.EOF, "read"), "read", "EOF")
+	AssertHasStack(t, errors.New("a"))
+}
@@ -0,0 +1,114 @@
+package errorstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// UpdateEnv 是更新 golden 文件的环境变量名, 例如 ERRORSTEST_UPDATE=1 go test。
+const UpdateEnv = "ERRORSTEST_UPDATE"
+
+// UpdateFlag 是更新 golden 文件的命令行参数名。
+// errorstest 不会定义该参数, 测试包自行定义了同名的 bool 参数时, 例如:
+//
+//	var update = flag.Bool("update", false, "update golden files")
+//
+// 使用 go test -update 运行测试同样会更新 golden 文件。
+const UpdateFlag = "update"
+
+var normalizers = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	// 绝对路径, 只保留文件名, 例如 /home/user/errors/stack.go
+	{regexp.MustCompile(`(^|[\s"'(\[])(?:[A-Za-z]:)?(?:[/\\][^/\\\s:"'()\[\]]+)*[/\\]([^/\\\s:"'()\[\]]+\.(?:go|s))\b`), "$1<path>/$2"},
+	// 行号, 例如 stack.go:42
+	{regexp.MustCompile(`\.(go|s):\d+`), ".$1:<line>"},
+	// goroutine ID, 例如 goroutine 17 [running] 与 created by main.main in goroutine 1
+	{regexp.MustCompile(`\bgoroutine \d+\b`), "goroutine <id>"},
+	// 程序计数器与函数参数中的地址, 例如 +0x1d 与 0xc000010000
+	{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`), "<pc>"},
+	// 匿名函数的编号随 Go 版本与代码位置变化, 例如 TestX.func1.2
+	{regexp.MustCompile(`\.func\d+(?:\.\d+)*`), ".func<n>"},
+}
+
+// Normalize 将 error 格式化输出中随机器与运行而变化的部分替换为固定的占位符, 用于快照测试:
+//
+//   - 绝对路径替换为 <path>/ 加文件名
+//   - 行号替换为 <line>
+//   - goroutine ID 替换为 <id>
+//   - 程序计数器等十六进制地址替换为 <pc>
+//   - 匿名函数的编号替换为 func<n>
+func Normalize(s string) string {
+	for _, n := range normalizers {
+		s = n.re.ReplaceAllString(s, n.repl)
+	}
+	return s
+}
+
+// AssertGolden 断言 Normalize(got) 与 golden 文件 testdata/<name>.golden 的内容相同。
+// 设置了环境变量 UpdateEnv, 或者使用 UpdateFlag 参数运行测试时, 将 Normalize(got) 写入 golden 文件, 而不是比较。
+func AssertGolden(t TB, name string, got string) bool {
+	t.Helper()
+	got = Normalize(got)
+
+	path := filepath.Join("testdata", name+".golden")
+	if update() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Errorf("update golden file %s: %v", path, err)
+			return false
+		}
+		if err := ioutil.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Errorf("update golden file %s: %v", path, err)
+			return false
+		}
+		return true
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Errorf("read golden file %s: %v (run with %s=1 to create it)", path, err, UpdateEnv)
+		return false
+	}
+	if got != string(want) {
+		t.Errorf("golden file %s mismatch (run with %s=1 to update it)\nwant:\n%s\ngot:\n%s", path, UpdateEnv, want, got)
+		return false
+	}
+	return true
+}
+
+// AssertGoldenJSON 与 AssertGolden 相同, 但先将 JSON 格式的 got 缩进, 使 golden 文件便于阅读与比较。
+func AssertGoldenJSON(t TB, name string, got []byte) bool {
+	t.Helper()
+	var b bytes.Buffer
+	if err := json.Indent(&b, got, "", "  "); err != nil {
+		t.Errorf("invalid JSON %q: %v", got, err)
+		return false
+	}
+	return AssertGolden(t, name, b.String()+"\n")
+}
+
+// update 报告是否需要更新 golden 文件。
+func update() bool {
+	if v := os.Getenv(UpdateEnv); v != "" {
+		b, err := strconv.ParseBool(v)
+		return err != nil || b
+	}
+
+	f := flag.Lookup(UpdateFlag)
+	if f == nil {
+		return false
+	}
+	g, ok := f.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+	v, _ := g.Get().(bool)
+	return v
+}
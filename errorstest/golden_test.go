@@ -0,0 +1,105 @@
+package errorstest
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/eachinchung/errors"
+)
+
+// 测试包自行定义 -update 参数时, 不能与 errorstest 冲突
+var updateFlag = flag.Bool(UpdateFlag, false, "update golden files")
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{
+			"github.com/eachinchung/errors.New\n\t/home/user/go/src/github.com/eachinchung/errors/errors.go:16",
+			"github.com/eachinchung/errors.New\n\t<path>/errors.go:<line>",
+		},
+		{
+			"runtime.goexit\n\tC:/Go/src/runtime/asm_amd64.s:1571 +0x1",
+			"runtime.goexit\n\t<path>/asm_amd64.s:<line> +<pc>",
+		},
+		{
+			`{"caller":"#0 /tmp/w/errors/errors.go:12 (github.com/eachinchung/errors.TestX.func1.2)"}`,
+			`{"caller":"#0 <path>/errors.go:<line> (github.com/eachinchung/errors.TestX.func<n>)"}`,
+		},
+		{
+			"goroutine 17 [running]:\nmain.f(0xc000012345)\ncreated by main.main in goroutine 1",
+			"goroutine <id> [running]:\nmain.f(<pc>)\ncreated by main.main in goroutine <id>",
+		},
+		{
+			"[]errors.Frame{0x4a2b3c, 0x4a2b3d}",
+			"[]errors.Frame{<pc>, <pc>}",
+		},
+		{
+			"errors/stack.go:42 relative path",
+			"errors/stack.go:<line> relative path",
+		},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q):\n got %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func complexChain() error {
+	err := errors.WithCode(io.EOF, errNotFound, "user not found")
+	err = errors.Wrap(err, "load user")
+	return errors.NewAggregate(err, errors.WithMessage(errors.New("timeout"), "fetch avatar"))
+}
+
+func TestGolden(t *testing.T) {
+	defer errors.SetFrameFilters()
+	errors.SetFrameFilters(errors.DropStdlib())
+
+	err := complexChain()
+	AssertGolden(t, "chain_text", fmt.Sprintf("%+v\n", err))
+	AssertGoldenJSON(t, "chain_json", []byte(fmt.Sprintf("%#-v", err)))
+}
+
+func TestAssertGolden_fail(t *testing.T) {
+	r := &recorder{}
+	if AssertGolden(r, "missing", "text") || len(r.failures) != 1 {
+		t.Errorf("AssertGolden: want failure for missing golden file, got %q", r.failures)
+	}
+
+	r = &recorder{}
+	if AssertGolden(r, "chain_text", "text") || len(r.failures) != 1 {
+		t.Errorf("AssertGolden: want failure for mismatch, got %q", r.failures)
+	}
+
+	r = &recorder{}
+	if AssertGoldenJSON(r, "chain_json", []byte("{")) || len(r.failures) != 1 {
+		t.Errorf("AssertGoldenJSON: want failure for invalid JSON, got %q", r.failures)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	defer os.Unsetenv(UpdateEnv)
+	defer flag.Set(UpdateFlag, fmt.Sprint(*updateFlag))
+
+	os.Unsetenv(UpdateEnv)
+	_ = flag.Set(UpdateFlag, "false")
+	if update() {
+		t.Error("update: want false by default")
+	}
+	_ = flag.Set(UpdateFlag, "true")
+	if !update() {
+		t.Errorf("update: want true with -%s", UpdateFlag)
+	}
+
+	_ = flag.Set(UpdateFlag, "false")
+	for v, want := range map[string]bool{"1": true, "true": true, "0": false, "false": false} {
+		os.Setenv(UpdateEnv, v)
+		if update() != want {
+			t.Errorf("update: want %v with %s=%s", want, UpdateEnv, v)
+		}
+	}
+}
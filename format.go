@@ -27,10 +27,10 @@ func format(k int, jsonData []map[string]interface{}, str *bytes.Buffer, info *f
 
 			caller := fmt.Sprintf("#%d", k)
 			if info.stack != nil {
-				f := Frame((*info.stack)[0])
+				f := info.stack.caller()
 				caller = fmt.Sprintf("%s %s:%d (%s)",
 					caller,
-					f.file(),
+					f.displayFile(),
 					f.line(),
 					f.name(),
 				)
@@ -43,12 +43,12 @@ func format(k int, jsonData []map[string]interface{}, str *bytes.Buffer, info *f
 	} else {
 		if flagDetail || flagTrace {
 			if info.stack != nil {
-				f := Frame((*info.stack)[0])
+				f := info.stack.caller()
 				fmt.Fprintf(str, "%s%s - #%d [%s:%d (%s)] (%d) %s",
 					sep,
 					info.err,
 					k,
-					f.file(),
+					f.displayFile(),
 					f.line(),
 					f.name(),
 					info.code,
@@ -66,19 +66,35 @@ func format(k int, jsonData []map[string]interface{}, str *bytes.Buffer, info *f
 	return jsonData, str
 }
 
-// list 将错误堆栈转换为一个简单的数组
-func list(e error) []error {
-	var ret []error
+// formatChain 按照 withCode.Format 的规则, 格式化 err 链中的每一个 error。
+// 没有 flagTrace 时, 只格式化最外层的 error。
+func formatChain(err error, flagDetail, flagTrace, modeJSON bool) ([]map[string]interface{}, *bytes.Buffer) {
+	str := bytes.NewBuffer([]byte{})
+	var jsonData []map[string]interface{}
 
-	if e != nil {
-		if w, ok := e.(interface{ Unwrap() error }); ok {
-			ret = append(ret, e)
-			ret = append(ret, list(w.Unwrap())...)
-		} else {
-			ret = append(ret, e)
+	sep := ""
+	errs := list(err)
+	length := len(errs)
+	for k, e := range errs {
+		info := buildFormatInfo(e)
+		jsonData, str = format(length-k-1, jsonData, str, info, sep, flagDetail, flagTrace, modeJSON)
+		sep = "; "
+
+		if !flagTrace {
+			break
 		}
 	}
 
+	return jsonData, str
+}
+
+// list 以深度优先的前序遍历, 将错误树转换为一个简单的数组
+func list(e error) []error {
+	var ret []error
+	walk(e, func(err error) bool {
+		ret = append(ret, err)
+		return false
+	})
 	return ret
 }
 
@@ -117,6 +133,13 @@ func buildFormatInfo(e error) *formatInfo {
 			err:     err.msg,
 			stack:   err.stack,
 		}
+	case *panicError:
+		info = &formatInfo{
+			code:    panicCoder.Code(),
+			message: panicCoder.String(),
+			err:     err.Error(),
+			stack:   err.stack,
+		}
 	default:
 		info = &formatInfo{
 			code:    unknownCoder.Code(),
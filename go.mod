@@ -3,6 +3,6 @@ module github.com/eachinchung/errors
 go 1.13
 
 require (
-	github.com/deckarep/golang-set v1.8.0
+	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.7.1
 )
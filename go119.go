@@ -0,0 +1,7 @@
+//go:build !go1.20
+// +build !go1.20
+
+package errors
+
+// isJoinError 报告 err 是否为标准库 errors.Join 的结果, Go 1.20 之前没有 errors.Join。
+func isJoinError(error) bool { return false }
@@ -0,0 +1,24 @@
+//go:build go1.20
+// +build go1.20
+
+package errors
+
+//goland:noinspection SpellCheckingInspection
+import (
+	stderrors "errors"
+	"reflect"
+)
+
+// Join 返回包装了给定 error 的 error, 见标准库的 errors.Join。
+// 忽略所有 nil 的 error, 如果 errs 全部为 nil, 则返回 nil。
+//
+// 结果可以直接传入 NewAggregate 或 Flatten, 其中的 error 会被展开为 Aggregate 的元素。
+func Join(errs ...error) error { return stderrors.Join(errs...) }
+
+// joinErrorType 为标准库 errors.Join 返回的 error 的类型
+var joinErrorType = reflect.TypeOf(stderrors.Join(stderrors.New("")))
+
+// isJoinError 报告 err 是否为标准库 errors.Join 的结果。
+func isJoinError(err error) bool {
+	return err != nil && reflect.TypeOf(err) == joinErrorType
+}
@@ -0,0 +1,79 @@
+//go:build go1.20
+// +build go1.20
+
+package errors
+
+//goland:noinspection SpellCheckingInspection
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoin(t *testing.T) {
+	assert.Nil(t, Join(nil, nil))
+
+	err := Join(io.EOF, nil, loadConfig())
+	assert.True(t, Is(err, io.EOF))
+	assert.True(t, IsCode(err, errInvalidJSON))
+	assert.Equal(t, errConfigurationNotValid, ParseCoder(err).Code())
+}
+
+func TestAggregateStdlibCompat(t *testing.T) {
+	pathErr := &os.PathError{Op: "open", Path: "a", Err: io.EOF}
+	agg := NewAggregate(io.ErrUnexpectedEOF, Wrap(pathErr, "wrap"))
+
+	var target *os.PathError
+	assert.True(t, stderrors.As(fmt.Errorf("wrap: %w", agg), &target))
+	assert.Equal(t, pathErr, target)
+	assert.True(t, stderrors.Is(stderrors.Join(agg, io.ErrClosedPipe), io.EOF))
+}
+
+func TestNewAggregateJoin(t *testing.T) {
+	agg := NewAggregate(stderrors.Join(io.EOF, io.ErrUnexpectedEOF), io.ErrClosedPipe)
+	assert.Equal(t, []error{io.EOF, io.ErrUnexpectedEOF, io.ErrClosedPipe}, agg.Errors())
+	assert.Equal(t, "[EOF, unexpected EOF, io: read/write on closed pipe]", agg.Error())
+
+	// 只展开 errors.Join 的结果, 而不展开其他的 Aggregate
+	nested := NewAggregate(agg)
+	assert.Len(t, nested.Errors(), 1)
+}
+
+func TestFlattenJoin(t *testing.T) {
+	agg := aggregate{io.EOF, stderrors.Join(io.ErrUnexpectedEOF, NewAggregate(io.ErrClosedPipe, stderrors.Join(io.ErrNoProgress)))}
+	assert.Equal(t, []error{io.EOF, io.ErrUnexpectedEOF, io.ErrClosedPipe, io.ErrNoProgress}, Flatten(agg).Errors())
+}
+
+func TestFilterOutJoin(t *testing.T) {
+	err := FilterOut(stderrors.Join(io.EOF, io.ErrUnexpectedEOF), func(err error) bool { return err == io.EOF })
+	assert.Equal(t, NewAggregate(io.ErrUnexpectedEOF), err)
+}
+
+func Test_aggregate_visitJoin(t *testing.T) {
+	agg := aggregate{io.EOF, stderrors.Join(io.ErrUnexpectedEOF, io.ErrClosedPipe)}
+	assert.True(t, agg.Is(io.ErrClosedPipe))
+	assert.Equal(t, "[EOF, unexpected EOF, io: read/write on closed pipe]", agg.Error())
+}
+
+func TestFormatJoin(t *testing.T) {
+	err := WithCode(Join(Code(errEOF, "a"), Code(errInvalidJSON, "b")), errConfigurationNotValid, "c")
+	var messages []string
+	for _, e := range list(err) {
+		messages = append(messages, e.Error())
+	}
+	assert.Equal(t, []string{"c", "a\nb", "a", "b"}, messages)
+
+	got := fmt.Sprintf("%+v", err)
+	assert.Contains(t, got, "(4) end of input")
+	assert.Contains(t, got, "(3) encoding failed due to an error with the data")
+}
+
+func TestCausesJoin(t *testing.T) {
+	root := New("root")
+	assert.Equal(t, []error{root, io.EOF}, Causes(Wrap(Join(WithStack(root), io.EOF), "wrap")))
+	assert.Equal(t, StackTraceOf(root), StackTraceOf(Join(io.EOF, Wrap(root, "wrap"))))
+}
@@ -0,0 +1,124 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// GoHandler 处理 Go 启动的 goroutine 返回的 error 或发生的 panic。
+type GoHandler func(ctx context.Context, err error)
+
+// GoOption 配置 Go 启动的 goroutine。
+type GoOption func(*goOptions)
+
+type goOptions struct {
+	handler GoHandler
+	label   string
+	done    func()
+}
+
+// WithGoHandler 使用 h 处理 goroutine 的 error, 而不是全局的 GoHandler。
+func WithGoHandler(h GoHandler) GoOption {
+	return func(o *goOptions) {
+		o.handler = h
+	}
+}
+
+// WithGoLabel 用 label 注释 goroutine 的 error, 见 WithMessage。
+func WithGoLabel(label string) GoOption {
+	return func(o *goOptions) {
+		o.label = label
+	}
+}
+
+// WithGoDone 在 goroutine 结束时调用 done, 如果 goroutine 失败, done 在 GoHandler 返回之后调用。
+func WithGoDone(done func()) GoOption {
+	return func(o *goOptions) {
+		o.done = done
+	}
+}
+
+var goHandler atomic.Value
+
+// SetGoHandler 设置全局的 GoHandler, h 为 nil 时恢复默认。
+// 默认的 GoHandler 使用标准库 log 的默认 Logger, 以 %+v 格式记录 error。
+func SetGoHandler(h GoHandler) {
+	goHandler.Store(h)
+}
+
+// GetGoHandler 返回当前全局的 GoHandler。
+func GetGoHandler() GoHandler {
+	if h, _ := goHandler.Load().(GoHandler); h != nil {
+		return h
+	}
+	return logGoError
+}
+
+func logGoError(_ context.Context, err error) {
+	stdLogger{}.Printf("errors: goroutine failed: %+v", err)
+}
+
+// Go 在新的 goroutine 中调用 f, f 返回的 error 与发生的 panic (见 Recover) 将交给 GoHandler 处理。
+// 交给 GoHandler 的 error 额外记录了调用 Go 处的堆栈, 以 %+v 格式化时,
+// 在 error 自身的堆栈之后输出 goroutine 的启动位置。该堆栈总是被记录, 不受 CapturePolicy 的影响。
+func Go(ctx context.Context, f func(ctx context.Context) error, opts ...GoOption) {
+	o := &goOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.handler == nil {
+		o.handler = GetGoHandler()
+	}
+	spawn := callers(3, maxStackDepth)
+
+	go func() {
+		if o.done != nil {
+			defer o.done()
+		}
+		err := Try(func() error { return f(ctx) })
+		if err == nil {
+			return
+		}
+		if o.label != "" {
+			err = WithMessage(err, o.label)
+		}
+		o.handler(ctx, &goError{cause: err, stack: spawn})
+	}()
+}
+
+// goError 记录了 goroutine 启动处的堆栈。
+type goError struct {
+	cause error
+	stack *stack
+}
+
+func (g *goError) Error() string { return g.cause.Error() }
+
+// Cause 返回 goroutine 的 error
+func (g *goError) Cause() error { return g.cause }
+
+// Unwrap 提供 Go 1.13 错误链的兼容性
+func (g *goError) Unwrap() error { return g.cause }
+
+//nolint:errcheck
+//goland:noinspection GoUnhandledErrorResult
+func (g *goError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%+v", g.cause)
+			if g.stack != nil {
+				io.WriteString(s, "\ngoroutine started at:")
+				g.stack.Format(s, verb)
+			}
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, g.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", g.Error())
+	}
+}
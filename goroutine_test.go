@@ -0,0 +1,100 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func goAndWait(f func(ctx context.Context) error, opts ...GoOption) error {
+	errc := make(chan error, 1)
+	opts = append(opts, WithGoHandler(func(ctx context.Context, err error) { errc <- err }))
+	Go(context.Background(), f, opts...)
+	return <-errc
+}
+
+func TestGo(t *testing.T) {
+	err := goAndWait(func(ctx context.Context) error { return Wrap(io.EOF, "read") })
+	assert.EqualError(t, err, "read: EOF")
+	assert.True(t, Is(err, io.EOF))
+	assert.Regexp(t, regexp.MustCompile(`^EOF\n`+
+		`read\n`+
+		`github.com/eachinchung/errors.TestGo.func\d+\n`+
+		`(?s:.*)`+
+		`\ngoroutine started at:\n`+
+		`github.com/eachinchung/errors.goAndWait\n`+
+		`\t.+/goroutine_test.go:\d+\n`+
+		`github.com/eachinchung/errors.TestGo\n`), fmt.Sprintf("%+v", err))
+}
+
+func TestGo_capturePolicy(t *testing.T) {
+	defer SetCapturePolicy(GetCapturePolicy())
+	SetCapturePolicy(CapturePolicy{Mode: CaptureNever})
+
+	err := goAndWait(func(ctx context.Context) error { return Wrap(io.EOF, "read") })
+	assert.Regexp(t, regexp.MustCompile(`^EOF\n`+
+		`read\n`+
+		`goroutine started at:\n`+
+		`github.com/eachinchung/errors.goAndWait\n`), fmt.Sprintf("%+v", err))
+}
+
+func TestGo_panic(t *testing.T) {
+	err := goAndWait(func(ctx context.Context) error { panic("boom") }, WithGoLabel("worker"))
+	assert.EqualError(t, err, "worker: panic: boom")
+	assert.True(t, IsCode(err, CodePanic))
+	assert.Regexp(t, regexp.MustCompile(`^panic: boom\n`+
+		`github.com/eachinchung/errors.TestGo_panic.func\d+\n`+
+		`(?s:.*)`+
+		`\nworker\n`+
+		`goroutine started at:\n`+
+		`github.com/eachinchung/errors.goAndWait\n`), fmt.Sprintf("%+v", err))
+}
+
+func TestGo_nil(t *testing.T) {
+	done := make(chan struct{})
+	var called int32
+	Go(context.Background(), func(ctx context.Context) error { return nil },
+		WithGoHandler(func(ctx context.Context, err error) { atomic.StoreInt32(&called, 1) }),
+		WithGoDone(func() { close(done) }))
+	<-done
+	assert.Zero(t, atomic.LoadInt32(&called), "Go: handler must not be called without error")
+}
+
+func TestGo_done(t *testing.T) {
+	var order []string
+	done := make(chan struct{})
+	Go(context.Background(), func(ctx context.Context) error { panic("boom") },
+		WithGoHandler(func(ctx context.Context, err error) { order = append(order, "handler") }),
+		WithGoDone(func() {
+			order = append(order, "done")
+			close(done)
+		}))
+	<-done
+	assert.Equal(t, []string{"handler", "done"}, order)
+}
+
+func TestSetGoHandler(t *testing.T) {
+	defer SetGoHandler(nil)
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "v")
+	got := make(chan interface{}, 1)
+	SetGoHandler(func(ctx context.Context, err error) { got <- ctx.Value(key{}) })
+	Go(ctx, func(ctx context.Context) error { return io.EOF })
+	assert.Equal(t, "v", <-got)
+
+	SetGoHandler(nil)
+	var logs bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&logs)
+	GetGoHandler()(ctx, io.EOF)
+	assert.Contains(t, logs.String(), "errors: goroutine failed: EOF")
+	assert.Equal(t, `"EOF"`, fmt.Sprintf("%q", &goError{cause: io.EOF}))
+}
@@ -0,0 +1,106 @@
+//go:build go1.13
+// +build go1.13
+
+package errors
+
+import (
+	"context"
+	"sync"
+)
+
+// Group 类似 golang.org/x/sync/errgroup, 但是收集所有的 error, 而不仅仅是第一个。
+//
+// Group 的零值可以直接使用, 此时没有并发限制, 也不会取消任何 context。
+// 函数中的 panic 会被转换为 error, 其中包含 panic 的值与 panic 处的堆栈。
+type Group struct {
+	cancel func()
+
+	wg  sync.WaitGroup
+	sem chan struct{}
+
+	mu          sync.Mutex
+	errs        []error
+	failFast    bool
+	failFastFns []Matcher
+}
+
+// GroupWithContext 返回一个新的 Group, 以及从 ctx 派生的 context。
+// 派生的 context 会在 Wait 返回时, 或启用 FailFast 后第一个匹配的 error 出现时被取消。
+func GroupWithContext(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// SetLimit 限制最多同时运行 n 个函数, n 小于等于 0 时不限制。
+// 必须在调用 Go 之前设置。
+func (g *Group) SetLimit(n int) {
+	if n <= 0 {
+		g.sem = nil
+		return
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// FailFast 启用快速失败: 第一个与任一 Matcher 匹配的 error 出现时, 取消 GroupWithContext 派生的 context。
+// 不传 Matcher 时, 任何 error 都会触发取消。
+// 必须在调用 Go 之前设置。
+func (g *Group) FailFast(fns ...Matcher) {
+	g.failFast = true
+	g.failFastFns = fns
+}
+
+// Go 在新的 goroutine 中运行 f。
+// 设置了 SetLimit 时, Go 将阻塞直到有空闲的位置。
+func (g *Group) Go(f func() error) {
+	g.GoLabel("", f)
+}
+
+// GoLabel 与 Go 相同, 但 f 返回的 error 将附带 label, 以便 Aggregate 说明是哪个任务失败,
+// 例如 "task-1: connection refused"。label 为空时不附带。
+func (g *Group) GoLabel(label string, f func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+
+	// 预留位置, 使 Wait 返回的 error 与调用 Go 的顺序一致
+	g.mu.Lock()
+	i := len(g.errs)
+	g.errs = append(g.errs, nil)
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+
+		err := Try(f)
+		if err == nil {
+			return
+		}
+		if g.failFast && g.cancel != nil && (len(g.failFastFns) == 0 || matchesError(err, g.failFastFns...)) {
+			g.cancel()
+		}
+		if label != "" {
+			err = WithMessage(err, label)
+		}
+
+		g.mu.Lock()
+		g.errs[i] = err
+		g.mu.Unlock()
+	}()
+}
+
+// Wait 等待所有通过 Go 启动的函数返回, 并将所有非 nil 的 error 按照调用 Go 的顺序填充到返回的 Aggregate 中。
+// 如果所有函数均成功完成, 则返回 nil。
+func (g *Group) Wait() Aggregate {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return NewAggregate(g.errs...)
+}
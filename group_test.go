@@ -0,0 +1,98 @@
+//go:build go1.13
+// +build go1.13
+
+package errors
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	var g Group
+	err1 := New("err-1")
+	g.Go(func() error {
+		time.Sleep(10 * time.Millisecond)
+		return err1
+	})
+	g.Go(func() error { return nil })
+	g.GoLabel("task-3", func() error { return io.EOF })
+	g.Go(func() error { panic("boom") })
+
+	agg := g.Wait()
+	errs := agg.Errors()
+	assert.Len(t, errs, 3)
+	assert.Equal(t, err1, errs[0])
+	assert.Equal(t, "task-3: EOF", errs[1].Error())
+	assert.True(t, Is(errs[1], io.EOF))
+	assert.Equal(t, "panic: boom", errs[2].Error())
+
+	var empty Group
+	assert.Nil(t, empty.Wait())
+}
+
+func TestGroupWithContext(t *testing.T) {
+	g, ctx := GroupWithContext(context.Background())
+	g.Go(func() error { return io.EOF })
+	assert.Equal(t, []error{io.EOF}, g.Wait().Errors())
+	assert.Equal(t, context.Canceled, ctx.Err(), "Wait must cancel the derived context")
+}
+
+func TestGroup_FailFast(t *testing.T) {
+	g, ctx := GroupWithContext(context.Background())
+	g.FailFast()
+	g.GoLabel("fail", func() error { return io.EOF })
+	g.GoLabel("wait", func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	agg := g.Wait()
+	assert.Equal(t, "[fail: EOF, wait: context canceled]", agg.Error())
+}
+
+func TestGroup_FailFastMatcher(t *testing.T) {
+	g, ctx := GroupWithContext(context.Background())
+	g.FailFast(func(err error) bool { return IsCode(err, errEOF) })
+
+	g.Go(func() error { return io.EOF })
+	time.Sleep(5 * time.Millisecond)
+	assert.Nil(t, ctx.Err(), "non-matching error must not cancel")
+
+	g.Go(func() error { return Code(errEOF, "eof") })
+	g.Go(func() error {
+		<-ctx.Done()
+		return nil
+	})
+	assert.Len(t, g.Wait().Errors(), 2)
+}
+
+func TestGroup_SetLimit(t *testing.T) {
+	var g Group
+	g.SetLimit(2)
+
+	var running, max int32
+	for i := 0; i < 10; i++ {
+		g.Go(func() error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+	}
+	assert.Nil(t, g.Wait())
+	assert.True(t, max <= 2, "max parallelism %d", max)
+
+	g.SetLimit(0)
+	assert.Nil(t, g.sem)
+}
@@ -0,0 +1,125 @@
+//go:build go1.13
+// +build go1.13
+
+package errors
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+)
+
+// WriteError 将 err 以 JSON 格式写入 w, HTTP 状态码与错误码由 ParseCoder 解析, 例如:
+//
+//	{"code":1001,"error":"资源不存在"}
+//
+// error 为错误码对应的外部 (用户) 错误信息, 为空时使用 HTTP 状态码的描述。
+// err 中有 *ValidationError 时, 额外包含 fields, 列出每个字段的错误, 见 ValidationError.MarshalJSON。
+// err 为 nil 时不写入任何内容。
+func WriteError(w http.ResponseWriter, err error) {
+	if err == nil {
+		return
+	}
+
+	var ve *ValidationError
+	As(err, &ve)
+	writeError(w, ParseCoder(err), ve)
+}
+
+// writeError 以 coder 的 HTTP 状态码, 将 coder 以 JSON 格式写入 w, ve 不为 nil 时额外包含 fields。
+//
+//nolint:errcheck
+//goland:noinspection GoUnhandledErrorResult
+func writeError(w http.ResponseWriter, coder Coder, ve *ValidationError) {
+	body := map[string]interface{}{
+		"code":  coder.Code(),
+		"error": coder.String(),
+	}
+	if coder.String() == "" {
+		body["error"] = http.StatusText(coder.HTTPStatus())
+	}
+	if ve != nil {
+		body["fields"] = ve.jsonData(false, false)["fields"]
+	}
+
+	b, _ := json.Marshal(body)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(coder.HTTPStatus())
+	w.Write(b)
+}
+
+// RecoverHandler 返回恢复 next 中 panic 的 http.Handler。
+// panic 将被转换为 error (见 Recover), 以 %+v 格式记录到 logger 中, logger 为 nil 时使用标准库 log 的默认 Logger;
+// 之后按照 WriteError 的 JSON 格式写入 CodePanic 对应的响应, 即 HTTP 500 与 {"code":50,"error":"内部服务器错误"}。
+// 即使 panic 的值携带了错误码, 响应也总是 CodePanic, panic 意味着服务器的内部错误, 错误码只会记录在日志中。
+// next 在 panic 前已经开始写入响应时, 只记录日志, 不再写入任何内容。
+// 与 net/http 相同, http.ErrAbortHandler 会被再次 panic, 以中止响应且不记录日志。
+// 传给 next 的 http.ResponseWriter 实现了 http.Flusher, 原始的 http.ResponseWriter 实现了 http.Hijacker 时同样实现。
+func RecoverHandler(next http.Handler, logger Logger) http.Handler {
+	if logger == nil {
+		logger = stdLogger{}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		var ww http.ResponseWriter = rw
+		if _, ok := w.(http.Hijacker); ok {
+			ww = hijackResponseWriter{rw}
+		}
+		err := Try(func() error {
+			next.ServeHTTP(ww, r)
+			return nil
+		})
+		if err == nil {
+			return
+		}
+		if p, ok := err.(*panicError); ok && p.value == http.ErrAbortHandler {
+			panic(http.ErrAbortHandler)
+		}
+
+		logger.Printf("http: panic serving %s %s: %+v", r.Method, r.URL, err)
+		if !rw.wroteHeader {
+			writeError(w, panicCoder, nil)
+		}
+	})
+}
+
+// responseWriter 记录是否已经开始写入响应。
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush 实现了 http.Flusher
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Unwrap 返回原始的 http.ResponseWriter, 用于 Go 1.20 的 http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// hijackResponseWriter 在原始的 http.ResponseWriter 实现了 http.Hijacker 时使用, 以支持 WebSocket 与 CONNECT。
+type hijackResponseWriter struct {
+	*responseWriter
+}
+
+// Hijack 实现了 http.Hijacker, 接管连接后不再写入任何响应
+func (w hijackResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
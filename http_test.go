@@ -0,0 +1,168 @@
+package errors
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{"coded", Wrap(Code(errBadRequest, "bad id"), "get user"), 400, `{"code":2000,"error":"bad request"}`},
+		{"unknown", New("oops"), 500, `{"code":1,"error":"内部服务器错误"}`},
+		{"empty external message", Code(errNotExt, "no ext"), 500, `{"code":6,"error":"Internal Server Error"}`},
+		{"validation", func() error {
+			v := NewValidation()
+			v.Addf("name", "is required")
+			return v.Err()
+		}(), 400, `{"code":40,"error":"参数错误","fields":[{"error":"is required","field":"name"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			WriteError(w, tt.err)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.wantBody, w.Body.String())
+		})
+	}
+
+	w := httptest.NewRecorder()
+	WriteError(w, nil)
+	assert.Equal(t, 0, w.Body.Len())
+}
+
+func TestWriteError_multiple(t *testing.T) {
+	defer SetCoderPolicy(GetCoderPolicy())
+	SetCoderPolicy(CoderPolicy{Mode: CoderMultiple})
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"most severe", NewAggregate(Code(errBadRequest, "a"), Code(errNotFound, "b")), http.StatusNotFound},
+		{"unknown", NewAggregate(Code(errBadRequest, "a"), New("b")), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			WriteError(w, tt.err)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.JSONEq(t, `{"code":20,"error":"多个错误"}`, w.Body.String())
+		})
+	}
+}
+
+func TestRecoverHandler(t *testing.T) {
+	var logs bytes.Buffer
+	logger := log.New(&logs, "", 0)
+
+	h := RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), logger)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"code":50,"error":"内部服务器错误"}`, w.Body.String())
+	assert.Regexp(t, regexp.MustCompile(`^http: panic serving GET /users/1: panic: boom\n`+
+		`github.com/eachinchung/errors.TestRecoverHandler.func\d+\n`+
+		`\t.+/http_test.go:\d+\n`), logs.String())
+}
+
+func TestRecoverHandler_coded(t *testing.T) {
+	var logs bytes.Buffer
+	h := RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(Code(errBadRequest, "bad id"))
+	}), log.New(&logs, "", 0))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"code":50,"error":"内部服务器错误"}`, w.Body.String())
+	assert.Contains(t, logs.String(), "bad id")
+}
+
+func TestRecoverHandler_started(t *testing.T) {
+	var logs bytes.Buffer
+	h := RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("partial"))
+		panic("boom")
+	}), log.New(&logs, "", 0))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "partial", w.Body.String())
+	assert.Contains(t, logs.String(), "panic: boom")
+}
+
+func TestRecoverHandler_ok(t *testing.T) {
+	var logs bytes.Buffer
+	h := RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte("ok"))
+	}), log.New(&logs, "", 0))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+	assert.Empty(t, logs.String())
+}
+
+func TestRecoverHandler_abort(t *testing.T) {
+	h := RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}), nil)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func TestRecoverHandler_hijack(t *testing.T) {
+	h := RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := w.(http.Flusher)
+		assert.True(t, ok)
+		hj, ok := w.(http.Hijacker)
+		if assert.True(t, ok) {
+			_, _, _ = hj.Hijack()
+		}
+		panic("boom")
+	}), log.New(&bytes.Buffer{}, "", 0))
+	w := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, w.hijacked)
+	assert.Equal(t, 0, w.Body.Len())
+
+	// 原始的 http.ResponseWriter 不支持时, 也不应当支持 http.Hijacker
+	h = RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := w.(http.Hijacker)
+		assert.False(t, ok)
+	}), nil)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
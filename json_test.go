@@ -55,3 +55,32 @@ func TestFrameMarshalJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestStackTraceMarshalJSON(t *testing.T) {
+	var tests = []struct {
+		StackTrace
+		want string
+	}{
+		{
+			nil,
+			`^\[\]$`,
+		},
+		{
+			StackTrace{0},
+			`^\[{"function":"unknown","package":"","file":"unknown","line":0}\]$`,
+		},
+		{
+			StackTrace{initpc},
+			`^\[{"function":"init(\.ializers)?","package":"github\.com/eachinchung/errors","file":".+/errors/stack_test\.go","line":\d+}\]$`,
+		},
+	}
+	for i, tt := range tests {
+		got, err := json.Marshal(tt.StackTrace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !regexp.MustCompile(tt.want).Match(got) {
+			t.Errorf("test %d: MarshalJSON:\n got %q\n want %q", i+1, string(got), tt.want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package errors
+
+import (
+	"log"
+)
+
+// Logger 记录错误日志, *log.Logger 实现了该接口。
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// stdLogger 使用标准库 log 的默认 Logger 记录日志。
+type stdLogger struct{}
+
+func (stdLogger) Printf(format string, v ...interface{}) { log.Printf(format, v...) }
@@ -0,0 +1,155 @@
+//go:build go1.13
+// +build go1.13
+
+package errors
+
+import (
+	"reflect"
+	"regexp"
+)
+
+// MatchIs 返回匹配 err 链中有 error 与 target 相同的 Matcher, 见 Is。
+func MatchIs(target error) Matcher {
+	return func(err error) bool {
+		return Is(err, target)
+	}
+}
+
+// MatchCode 返回匹配 err 树中有 error 包含错误码 code 的 Matcher, 见 IsCode。
+func MatchCode(code int) Matcher {
+	return func(err error) bool {
+		return IsCode(err, code)
+	}
+}
+
+// MatchCodeRange 返回匹配 err 树中有 error 的错误码在 [lo, hi] 范围内的 Matcher。
+func MatchCodeRange(lo, hi int) Matcher {
+	return func(err error) bool {
+		return walk(err, func(err error) bool {
+			code, ok := errorCode(err)
+			return ok && lo <= code && code <= hi
+		})
+	}
+}
+
+// MatchType 返回匹配 err 链中有 error 可以赋值给 target 指向的类型的 Matcher, 见 As。
+// 与 As 不同, target 只用于确定类型, 可以是 nil 指针, 例如:
+//
+//	errors.FilterOut(err, errors.MatchType((**os.PathError)(nil)))
+//
+// 如果 target 不是指针, 或者指向的类型既不是接口也没有实现 error, MatchType 将会 panic。
+func MatchType(target interface{}) Matcher {
+	if target == nil {
+		panic("errors: target cannot be nil")
+	}
+	typ := reflect.TypeOf(target)
+	if typ.Kind() != reflect.Ptr {
+		panic("errors: target must be a pointer")
+	}
+	elemType := typ.Elem()
+	if elemType.Kind() != reflect.Interface && !elemType.Implements(errorType) {
+		panic("errors: *target must be interface or implement error")
+	}
+
+	return func(err error) bool {
+		return As(err, reflect.New(elemType).Interface())
+	}
+}
+
+// MatchMessage 返回匹配 Error() 与正则表达式 re 匹配的 Matcher。
+func MatchMessage(re *regexp.Regexp) Matcher {
+	return func(err error) bool {
+		return re.MatchString(err.Error())
+	}
+}
+
+// And 返回当 fns 全部匹配时才匹配的 Matcher, 没有 fns 时总是匹配。
+func And(fns ...Matcher) Matcher {
+	return func(err error) bool {
+		for _, fn := range fns {
+			if !fn(err) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or 返回当 fns 中任何一个匹配时即匹配的 Matcher, 没有 fns 时总是不匹配。
+func Or(fns ...Matcher) Matcher {
+	return func(err error) bool {
+		return matchesError(err, fns...)
+	}
+}
+
+// Not 返回与 fn 结果相反的 Matcher。
+func Not(fn Matcher) Matcher {
+	return func(err error) bool {
+		return !fn(err)
+	}
+}
+
+// Partition 将 err 中的错误分为与 Matcher 匹配和不匹配的两部分, 不匹配的部分与 FilterOut 的结果相同。
+// 如果输入是非 Aggregate error, 则仅测试该错误, 其结果为只包含该错误的 Aggregate。
+// 如果输入 Aggregate error 或标准库 errors.Join 的结果, 错误列表将被递归处理, 并保留嵌套的结构。
+// 没有错误的部分为 nil。
+func Partition(err error, fns ...Matcher) (matched, unmatched Aggregate) {
+	if err == nil {
+		return nil, nil
+	}
+
+	list, ok := members(err)
+	if !ok {
+		if matchesError(err, fns...) {
+			return NewAggregate(err), nil
+		}
+		return nil, NewAggregate(err)
+	}
+
+	var m, u []error
+	for _, e := range list {
+		if _, ok := members(e); !ok {
+			if e == nil {
+				continue
+			}
+			if matchesError(e, fns...) {
+				m = append(m, e)
+			} else {
+				u = append(u, e)
+			}
+			continue
+		}
+
+		em, eu := Partition(e, fns...)
+		if em != nil {
+			m = append(m, em)
+		}
+		if eu != nil {
+			u = append(u, eu)
+		}
+	}
+	return NewAggregate(m...), NewAggregate(u...)
+}
+
+// Find 按访问顺序返回 err 中第一个与 Matcher 匹配的错误, 没有匹配的错误时返回 nil。
+// 如果输入 Aggregate error 或标准库 errors.Join 的结果, 将递归访问其中的每一个错误。
+func Find(err error, fns ...Matcher) error {
+	if err == nil {
+		return nil
+	}
+
+	list, ok := members(err)
+	if !ok {
+		if matchesError(err, fns...) {
+			return err
+		}
+		return nil
+	}
+
+	for _, e := range list {
+		if found := Find(e, fns...); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package errors
+
+import (
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchers(t *testing.T) {
+	pathErr := &os.PathError{Op: "open", Path: "/tmp", Err: io.EOF}
+	coded := Wrap(Code(errEOF, "eof"), "read")
+
+	tests := []struct {
+		name string
+		m    Matcher
+		err  error
+		want bool
+	}{
+		{"MatchIs", MatchIs(io.EOF), Wrap(io.EOF, "read"), true},
+		{"MatchIs not", MatchIs(io.EOF), io.ErrUnexpectedEOF, false},
+		{"MatchCode", MatchCode(errEOF), coded, true},
+		{"MatchCode not", MatchCode(errEOF), io.EOF, false},
+		{"MatchCodeRange", MatchCodeRange(errConfigurationNotValid, errEOF), coded, true},
+		{"MatchCodeRange lower", MatchCodeRange(errEOF+1, errEOF+10), coded, false},
+		{"MatchCodeRange nested", MatchCodeRange(errEOF, errEOF), WithCode(coded, 3000, "outer"), true},
+		{"MatchType", MatchType((**os.PathError)(nil)), Wrap(pathErr, "load"), true},
+		{"MatchType not", MatchType((**os.PathError)(nil)), io.EOF, false},
+		{"MatchType interface", MatchType((*net.Error)(nil)), pathErr, false},
+		{"MatchMessage", MatchMessage(regexp.MustCompile(`^read$`)), coded, true},
+		{"MatchMessage not", MatchMessage(regexp.MustCompile(`^eof`)), coded, false},
+		{"And", And(MatchIs(io.EOF), MatchType((**os.PathError)(nil))), pathErr, true},
+		{"And not", And(MatchIs(io.EOF), MatchCode(errEOF)), pathErr, false},
+		{"And empty", And(), io.EOF, true},
+		{"Or", Or(MatchCode(errEOF), MatchIs(io.EOF)), pathErr, true},
+		{"Or not", Or(MatchCode(errEOF), MatchIs(io.ErrClosedPipe)), pathErr, false},
+		{"Or empty", Or(), io.EOF, false},
+		{"Not", Not(MatchIs(io.EOF)), io.ErrClosedPipe, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.m(tt.err))
+		})
+	}
+}
+
+func TestMatchType_panic(t *testing.T) {
+	assert.Panics(t, func() { MatchType(nil) })
+	assert.Panics(t, func() { MatchType(io.EOF) })
+	assert.Panics(t, func() { MatchType((*string)(nil)) })
+}
+
+func TestPartition(t *testing.T) {
+	a, b, c := New("a"), Code(errEOF, "b"), New("c")
+
+	tests := []struct {
+		name          string
+		err           error
+		wantMatched   Aggregate
+		wantUnmatched Aggregate
+	}{
+		{"nil", nil, nil, nil},
+		{"single matched", b, aggregate{b}, nil},
+		{"single unmatched", a, nil, aggregate{a}},
+		{"flat", aggregate{a, b, c}, aggregate{b}, aggregate{a, c}},
+		{"nested", aggregate{a, aggregate{b, c}, aggregate{b}}, aggregate{aggregate{b}, aggregate{b}}, aggregate{a, aggregate{c}}},
+		{"all matched", aggregate{b, b}, aggregate{b, b}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, unmatched := Partition(tt.err, MatchCode(errEOF))
+			assert.Equal(t, tt.wantMatched, matched)
+			assert.Equal(t, tt.wantUnmatched, unmatched)
+			if tt.err != nil {
+				assert.Equal(t, FilterOut(tt.err, MatchCode(errEOF)) == nil, unmatched == nil)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	a, b, c := New("a"), Code(errEOF, "b"), Wrap(io.EOF, "c")
+	agg := aggregate{a, aggregate{c, b}, b}
+
+	assert.Nil(t, Find(nil, MatchIs(io.EOF)))
+	assert.Equal(t, c, Find(agg, MatchIs(io.EOF)))
+	assert.Equal(t, b, Find(agg, MatchCode(errEOF)))
+	assert.Nil(t, Find(a))
+	assert.Nil(t, Find(agg, MatchIs(io.ErrClosedPipe)))
+	assert.Equal(t, c, Find(c, MatchIs(io.EOF)))
+}
+
+func TestPartition_validation(t *testing.T) {
+	ve, q := testItem{"", 0}.validate(), New("q")
+	err := NewAggregate(ve, q)
+
+	assert.Equal(t, ve, Find(err, MatchCode(CodeInvalidArgument)))
+	matched, unmatched := Partition(err, MatchCode(CodeInvalidArgument))
+	assert.Equal(t, aggregate{ve}, matched)
+	assert.Equal(t, aggregate{q}, unmatched)
+
+	matched, unmatched = Partition(ve, MatchCode(CodeInvalidArgument))
+	assert.Equal(t, aggregate{ve}, matched)
+	assert.Nil(t, unmatched)
+}
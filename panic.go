@@ -0,0 +1,119 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+)
+
+// Recover 将当前 goroutine 中的 panic 转换为 error, 并赋值给 errp 指向的 error。
+// 必须直接以 defer 的方式调用, 例如:
+//
+//	func worker() (err error) {
+//	       defer errors.Recover(&err)
+//	       ...
+//	}
+//
+// 转换得到的 error 记录了 panic 的值与 panic 处 (而不是 recover 处) 的堆栈, 错误码为 CodePanic。
+// panic 的值为 error 时 (包括 runtime.Error), 可以通过 errors.Is 与 errors.As 访问该 error;
+// 该 error 在本包中携带错误码时, ParseCoder 将使用该错误码, 而不是 CodePanic。
+// 没有发生 panic 时, errp 指向的 error 保持不变。
+func Recover(errp *error) {
+	if r := recover(); r != nil {
+		*errp = newPanicError(r)
+	}
+}
+
+// Try 调用 f, 并返回 f 的结果, f 中的 panic 将被转换为 error, 见 Recover。
+func Try(f func() error) (err error) {
+	defer Recover(&err)
+	return f()
+}
+
+// panicError 由 panic 转换而来的 error, 记录了 panic 的值与 panic 处的堆栈。
+type panicError struct {
+	value interface{}
+	*stack
+}
+
+// newPanicError 将 recover 得到的 panic 值转换为 error。
+// 必须在 defer 的函数中调用, 记录的堆栈从 panic 处开始, 而不是 recover 处。
+// panic 的值已经是由 panic 转换而来的 error 时, 直接返回该 error。
+func newPanicError(value interface{}) error {
+	if err, ok := value.(*panicError); ok {
+		return err
+	}
+	return &panicError{
+		value: value,
+		stack: panicCallers(),
+	}
+}
+
+func (p *panicError) Error() string { return fmt.Sprintf("panic: %v", p.value) }
+
+// Value 返回 panic 的值
+func (p *panicError) Value() interface{} { return p.value }
+
+// Unwrap 当 panic 的值为 error 时, 返回该 error
+func (p *panicError) Unwrap() error {
+	if err, ok := p.value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// errorCode 当 panic 的值没有携带错误码时, 返回 CodePanic
+func (p *panicError) errorCode() (int, bool) {
+	coded := walk(p.Unwrap(), func(err error) bool {
+		_, ok := errorCode(err)
+		return ok
+	})
+	return CodePanic, !coded
+}
+
+//nolint:errcheck
+//goland:noinspection GoUnhandledErrorResult
+func (p *panicError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			io.WriteString(s, p.Error())
+			p.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, p.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", p.Error())
+	}
+}
+
+// panicCallers 记录 panic 处的堆栈。
+// 在 defer 的函数中, 堆栈依次为 recover 所在的函数、runtime.gopanic 以及 panic 处,
+// 运行时错误 (例如空指针) 在 runtime.gopanic 与 panic 处之间还有 runtime 的帧, 这些帧都将被跳过。
+// 不在 panic 过程中调用时, 记录调用者的堆栈。
+func panicCallers() *stack {
+	const depth = maxStackDepth * 2
+	var pcs [depth]uintptr
+	n := runtime.Callers(3, pcs[:])
+
+	start := 0
+	for i := 0; i < n; i++ {
+		if Frame(pcs[i]).name() == "runtime.gopanic" {
+			start = i + 1
+			for start < n && strings.HasPrefix(Frame(pcs[start]).name(), "runtime.") {
+				start++
+			}
+			break
+		}
+	}
+
+	end := start + maxStackDepth
+	if end > n {
+		end = n
+	}
+	var st stack = pcs[start:end]
+	return &st
+}
@@ -0,0 +1,137 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
+	"testing"
+)
+
+func panicked(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = newPanicError(r)
+		}
+	}()
+	f()
+	return nil
+}
+
+func TestPanicError(t *testing.T) {
+	tests := []struct {
+		err    error
+		format string
+		want   string
+	}{
+		{
+			panicked(func() { panic("boom") }),
+			"%s",
+			"^panic: boom$",
+		},
+		{
+			panicked(func() { panic(io.EOF) }),
+			"%q",
+			`^"panic: EOF"$`,
+		},
+		{
+			panicked(func() { panic("boom") }),
+			"%+v",
+			"^panic: boom\\n" +
+				"github.com/eachinchung/errors.TestPanicError.func\\d+\\n" +
+				"\\t.+/errors/panic_test.go:\\d+\\n" +
+				"github.com/eachinchung/errors.panicked\\n",
+		},
+		{
+			panicked(func() {
+				var p *int
+				_ = *p
+			}),
+			"%+v",
+			"^panic: runtime error: invalid memory address or nil pointer dereference\\n" +
+				"github.com/eachinchung/errors.TestPanicError.func\\d+\\n",
+		},
+	}
+	for i, tt := range tests {
+		got := fmt.Sprintf(tt.format, tt.err)
+		if !regexp.MustCompile(tt.want).MatchString(got) {
+			t.Errorf("test %d: %s:\n got %q\nwant %q", i+1, tt.format, got, tt.want)
+		}
+	}
+
+	if err := panicked(func() { panic(io.EOF) }); !Is(err, io.EOF) {
+		t.Errorf("Is: want %v in %v", io.EOF, err)
+	}
+	if err := newPanicError("boom"); StackTraceOf(err)[0].name() != "github.com/eachinchung/errors.TestPanicError" {
+		t.Errorf("newPanicError: want caller stack, got %+v", err)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	worker := func(v interface{}) (err error) {
+		defer Recover(&err)
+		if v != nil {
+			panic(v)
+		}
+		return io.EOF
+	}
+
+	if err := worker(nil); err != io.EOF {
+		t.Errorf("Recover: want %v, got %v", io.EOF, err)
+	}
+
+	err := worker("boom")
+	if got := err.Error(); got != "panic: boom" {
+		t.Errorf("Recover: want %q, got %q", "panic: boom", got)
+	}
+	if got := ParseCoder(err); got.Code() != CodePanic || got.HTTPStatus() != 500 {
+		t.Errorf("Recover: want code %d, got %d", CodePanic, got.Code())
+	}
+	if !IsCode(err, CodePanic) {
+		t.Errorf("IsCode: want %d in %v", CodePanic, err)
+	}
+	if name := StackTraceOf(err)[0].name(); !regexp.MustCompile(`TestRecover\.func\d+$`).MatchString(name) {
+		t.Errorf("Recover: want panic site, got %s", name)
+	}
+
+	err = worker(Code(errEOF, "eof"))
+	if got := ParseCoder(err).Code(); got != errEOF {
+		t.Errorf("Recover coded value: want code %d, got %d", errEOF, got)
+	}
+	if IsCode(err, CodePanic) {
+		t.Errorf("Recover coded value: want no %d in %v", CodePanic, err)
+	}
+
+	inner := worker("inner")
+	if err := worker(inner); err != inner {
+		t.Errorf("Recover panic error: want %v, got %v", inner, err)
+	}
+}
+
+func TestTry(t *testing.T) {
+	if err := Try(func() error { return nil }); err != nil {
+		t.Errorf("Try: want nil, got %v", err)
+	}
+	if err := Try(func() error { return io.EOF }); err != io.EOF {
+		t.Errorf("Try: want %v, got %v", io.EOF, err)
+	}
+
+	err := Try(func() error {
+		var s []int
+		_ = s[1]
+		return nil
+	})
+	var re runtime.Error
+	if !As(err, &re) {
+		t.Fatalf("Try: want runtime.Error in %v", err)
+	}
+	if name := StackTraceOf(err)[0].name(); !regexp.MustCompile(`TestTry\.func\d+$`).MatchString(name) {
+		t.Errorf("Try: want panic site, got %s", name)
+	}
+	if got := fmt.Sprintf("%-v", WithCode(err, errEOF, "worker failed")); !regexp.MustCompile(`^worker failed - #2 \[.+panic_test.go:\d+ .+\] \(4\) end of input$`).MatchString(got) {
+		t.Errorf("Try: %%-v: got %q", got)
+	}
+	if got := fmt.Sprintf("%+v", WithCode(err, errEOF, "worker failed")); !regexp.MustCompile(`; panic: runtime error: index out of range \[1\] with length 0 - #1 \[.+panic_test.go:\d+ .+\] \(50\) 内部服务器错误`).MatchString(got) {
+		t.Errorf("Try: %%+v: got %q", got)
+	}
+}
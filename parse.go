@@ -0,0 +1,412 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// fileLineRe 匹配堆栈中的源文件行, 例如 "\t/path/main.go:10 +0x1d"
+	fileLineRe = regexp.MustCompile(`^\t(.+):(\d+)(?: \+0x[0-9a-f]+)?$`)
+
+	// sourceLineRe 匹配 SetSourceContext 输出的源码行, 例如 "\t> 10 | return err"
+	sourceLineRe = regexp.MustCompile(`^\t[ >] +\d+ \|`)
+
+	// moreRe 匹配 StackModeCompact 输出的省略行, 例如 "... 3 more"
+	moreRe = regexp.MustCompile(`^\.\.\. (\d+) more$`)
+
+	// goroutineRe 匹配 goroutine 头部, 例如 "goroutine 1 [running]:"
+	goroutineRe = regexp.MustCompile(`^goroutine (\d+) \[(.*)\]:$`)
+
+	// createdByRe 匹配创建 goroutine 的函数, 例如 "created by main.main in goroutine 1"
+	createdByRe = regexp.MustCompile(`^created by (.+?)(?: in goroutine \d+)?$`)
+
+	// codeMarkerRe 匹配 withCode 的 %-v 与 %+v 输出中每个 error 的编号, 例如 " - #1 "
+	codeMarkerRe = regexp.MustCompile(` - #(\d+) `)
+
+	// codeTailRe 匹配 withCode 的 %-v 与 %+v 输出中编号之后的部分,
+	// 例如 "[/path/main.go:10 (main.main)] (1001) message"
+	codeTailRe = regexp.MustCompile(`^\[(.*):(\d+) \((.*)\)\] \((-?\d+)\) (.*)$`)
+)
+
+// ParsedError 从 %+v 文本中解析出的 error 链。
+type ParsedError struct {
+	// Chain error 链, 从根因到最外层的包装
+	Chain []ParsedLayer
+}
+
+// ParsedLayer 解析出的 error 链中的一层。
+type ParsedLayer struct {
+	// Message error 消息
+	Message string
+
+	// Code 错误码, 仅 withCode 的输出中包含, 否则为 0
+	Code int
+
+	// External 错误码对应的外部 (用户) 面对的错误信息, 仅 withCode 的输出中包含
+	External string
+
+	// Stack 该层记录的堆栈
+	Stack []FrameInfo
+}
+
+// ParsedPanic 从 panic 或 goroutine dump 文本中解析出的信息。
+type ParsedPanic struct {
+	// Values panic 的值, 嵌套 panic 时按输出顺序排列
+	Values []string
+
+	// Goroutines 所有 goroutine 的堆栈
+	Goroutines []ParsedGoroutine
+}
+
+// ParsedGoroutine 解析出的 goroutine。
+type ParsedGoroutine struct {
+	// ID goroutine 的编号
+	ID int
+
+	// State goroutine 的状态, 例如 running 或 "chan receive, 5 minutes"
+	State string
+
+	// Stack goroutine 的堆栈, 从最内层到最外层
+	Stack []FrameInfo
+
+	// CreatedBy 创建该 goroutine 的位置, 主 goroutine 为 nil
+	CreatedBy *FrameInfo
+}
+
+// ParseError 将本包 %+v 输出的文本解析为 error 链, 文本为空时返回 nil。
+//
+// 支持 fundamental、withStack 与 withMessage 的 %+v 多行输出,
+// 以及 withCode 的 %-v 与 %+v 单行输出。
+// StackModeCompact 省略的帧会从最深的堆栈中还原, SetSourceContext 输出的源码行会被忽略。
+// withStack 没有自己的消息, 其所在层的 Message 为空; 多行的 error 消息会被解析为多层。
+// 堆栈只记录了调用点 (CaptureCallSite) 或在最大深度处被截断时, 相邻的 withStack 可能被合并为一层。
+func ParseError(text string) *ParsedError {
+	text = strings.Trim(text, "\r\n")
+	if text == "" {
+		return nil
+	}
+
+	if !strings.Contains(text, "\n") {
+		if chain := parseCodeChain(text); chain != nil {
+			return &ParsedError{Chain: chain}
+		}
+	}
+	return &ParsedError{Chain: parseStackChain(splitLines(text))}
+}
+
+// ParseFrames 解析文本中所有的堆栈帧, 包括本包的 %+v 输出与 panic 输出。
+func ParseFrames(text string) []FrameInfo {
+	var frames []FrameInfo
+	lines := splitLines(text)
+	for i := 0; i+1 < len(lines); i++ {
+		if f, ok := parseFrame(lines[i], lines[i+1]); ok {
+			frames = append(frames, f)
+			i++
+		}
+	}
+	return frames
+}
+
+// trimRecovered 去掉 panic 值之后运行时附加的 " [recovered]" 或 " [recovered, repanicked]"。
+func trimRecovered(v string) string {
+	for _, suffix := range []string{" [recovered]", " [recovered, repanicked]"} {
+		if strings.HasSuffix(v, suffix) {
+			return strings.TrimSuffix(v, suffix)
+		}
+	}
+	return v
+}
+
+// ParsePanic 将 panic 或 goroutine dump 的文本解析为结构化的信息, 没有 goroutine 时返回 nil。
+func ParsePanic(text string) *ParsedPanic {
+	var (
+		p     ParsedPanic
+		g     *ParsedGoroutine
+		lines = splitLines(text)
+	)
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if m := goroutineRe.FindStringSubmatch(line); m != nil {
+			id, _ := strconv.Atoi(m[1])
+			p.Goroutines = append(p.Goroutines, ParsedGoroutine{ID: id, State: m[2]})
+			g = &p.Goroutines[len(p.Goroutines)-1]
+			continue
+		}
+
+		if g == nil {
+			if v := strings.TrimLeft(line, "\t"); strings.HasPrefix(v, "panic: ") {
+				p.Values = append(p.Values, trimRecovered(strings.TrimPrefix(v, "panic: ")))
+			}
+			continue
+		}
+
+		if i+1 >= len(lines) {
+			break
+		}
+		if m := createdByRe.FindStringSubmatch(line); m != nil {
+			if f, ok := parseFrame(m[1], lines[i+1]); ok {
+				g.CreatedBy = &f
+				i++
+			}
+			continue
+		}
+		if f, ok := parseFrame(line, lines[i+1]); ok {
+			g.Stack = append(g.Stack, f)
+			i++
+		}
+	}
+
+	if len(p.Goroutines) == 0 {
+		return nil
+	}
+	return &p
+}
+
+// Messages 返回 error 链中每一层的消息, 从根因到最外层的包装。
+func (e *ParsedError) Messages() []string {
+	messages := make([]string, len(e.Chain))
+	for i, l := range e.Chain {
+		messages[i] = l.Message
+	}
+	return messages
+}
+
+// Format 以 fundamental、withStack 与 withMessage 的 %+v 多行格式重新输出 error 链。
+//
+//	%s    最外层的消息
+//	%v    与 %s 相同
+//	%+v   每一层的消息与堆栈
+//
+//goland:noinspection GoUnhandledErrorResult
+func (e *ParsedError) Format(s fmt.State, verb rune) {
+	if len(e.Chain) == 0 {
+		return
+	}
+
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			for i, l := range e.Chain {
+				if i > 0 && l.Message != "" {
+					io.WriteString(s, "\n")
+				}
+				io.WriteString(s, l.Message)
+				for _, f := range l.Stack {
+					fmt.Fprintf(s, "\n%s\n\t%s:%d", f.name(), f.File, f.Line)
+				}
+			}
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, e.Chain[len(e.Chain)-1].Message)
+	}
+}
+
+// parseStackChain 解析 fundamental、withStack 与 withMessage 的 %+v 多行输出。
+//
+// 相邻的 withStack 之间没有消息行, 以下列位置作为上一个堆栈的结束:
+//   - runtime.goexit 帧
+//   - 与根帧相同的帧, 根帧为最后一个之后是消息行或文本结尾的帧, 即完整堆栈的最外层, 例如 DropRuntime 时的 testing.tRunner
+//   - StackModeCompact 输出的 "... N more" 行, 该模式下包装点只记录调用点, 其前一帧单独成为一个堆栈
+//
+// 每个堆栈只有一帧 (CaptureCallSite), 或者堆栈在 maxStackDepth 处被截断时, 相邻的 withStack 无法区分, 将被合并为一层。
+func parseStackChain(lines []string) []ParsedLayer {
+	var chain []ParsedLayer
+	layer := func() *ParsedLayer {
+		if len(chain) == 0 {
+			chain = append(chain, ParsedLayer{})
+		}
+		return &chain[len(chain)-1]
+	}
+
+	root, hasRoot := rootFrame(lines)
+	ended := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case sourceLineRe.MatchString(line):
+		case moreRe.MatchString(line):
+			// 省略的帧与最深的堆栈, 即第一个带有堆栈的层的尾部相同
+			n, _ := strconv.Atoi(moreRe.FindStringSubmatch(line)[1])
+			for _, r := range chain {
+				if len(r.Stack) > 0 {
+					if n <= len(r.Stack) {
+						l := layer()
+						l.Stack = append(l.Stack, r.Stack[len(r.Stack)-n:]...)
+					}
+					break
+				}
+			}
+			ended = true
+		case i+1 < len(lines) && fileLineRe.MatchString(lines[i+1]):
+			if f, ok := parseFrame(line, lines[i+1]); ok {
+				// 上一个堆栈已经完整结束, 紧随其后的堆栈属于没有消息的 withStack
+				l := layer()
+				if n := len(l.Stack); n > 0 && (ended || beforeMore(lines, i+2) ||
+					l.Stack[n-1].name() == "runtime.goexit" || hasRoot && l.Stack[n-1] == root) {
+					chain = append(chain, ParsedLayer{})
+					l = layer()
+				}
+				l.Stack = append(l.Stack, f)
+				ended = false
+				i++
+			}
+		case line == "" && i == 0:
+		default:
+			chain = append(chain, ParsedLayer{Message: line})
+			ended = false
+		}
+	}
+	return chain
+}
+
+// beforeMore 报告跳过源码行后, 第 i 行是否为 "... N more" 行。
+func beforeMore(lines []string, i int) bool {
+	for i < len(lines) && sourceLineRe.MatchString(lines[i]) {
+		i++
+	}
+	return i < len(lines) && moreRe.MatchString(lines[i])
+}
+
+// rootFrame 返回最后一个之后是消息行或文本结尾的帧, 即完整堆栈的最外层。
+func rootFrame(lines []string) (FrameInfo, bool) {
+	for i := len(lines) - 2; i >= 0; i-- {
+		if !fileLineRe.MatchString(lines[i+1]) {
+			continue
+		}
+
+		j := i + 2
+		for j < len(lines) && sourceLineRe.MatchString(lines[j]) {
+			j++
+		}
+		if beforeMore(lines, j) || j+1 < len(lines) && fileLineRe.MatchString(lines[j+1]) {
+			continue
+		}
+		if f, ok := parseFrame(lines[i], lines[i+1]); ok {
+			return f, true
+		}
+	}
+	return FrameInfo{}, false
+}
+
+// parseCodeChain 解析 withCode 的 %-v 与 %+v 单行输出, 无法解析时返回 nil。
+//
+// 每个 error 的格式为 "<error> - #<k> [<file>:<line> (<function>)] (<code>) <message>",
+// 编号 k 从最外层的 len-1 依次递减到根因的 0, 相邻的 error 之间以 "; " 分隔。
+// 消息中可能包含 "; ", 因此以编号连续的 " - #k " 定位每个 error,
+// 并以其后第一个 "; " 作为与下一个 error 的分界。
+func parseCodeChain(text string) []ParsedLayer {
+	// 消息本身也可能包含 " - #k ", 从后向前只保留编号连续递增的部分
+	all := codeMarkerRe.FindAllStringSubmatchIndex(text, -1)
+	var markers [][]int
+	next := -1
+	for i := len(all) - 1; i >= 0; i-- {
+		m := all[i]
+		k, _ := strconv.Atoi(text[m[2]:m[3]])
+		if next >= 0 && k != next {
+			continue
+		}
+		markers = append([][]int{m}, markers...)
+		next = k + 1
+	}
+	if len(markers) == 0 {
+		return nil
+	}
+
+	chain := make([]ParsedLayer, len(markers))
+	start := 0
+	for i, m := range markers {
+		end := len(text)
+		if i+1 < len(markers) {
+			sep := strings.Index(text[m[1]:markers[i+1][0]], "; ")
+			if sep < 0 {
+				return nil
+			}
+			end = m[1] + sep
+		}
+
+		l := ParsedLayer{Message: text[start:m[0]]}
+		tail := text[m[1]:end]
+		if t := codeTailRe.FindStringSubmatch(tail); t != nil {
+			line, _ := strconv.Atoi(t[2])
+			l.Code, _ = strconv.Atoi(t[4])
+			l.External = t[5]
+			l.Stack = []FrameInfo{newFrameInfo(t[3], t[1], line)}
+		} else {
+			l.External = tail
+		}
+
+		// 最外层在前, 根因在后, 倒序排列
+		chain[len(markers)-1-i] = l
+		start = end + len("; ")
+	}
+	return chain
+}
+
+// parseFrame 将函数行与源文件行解析为 FrameInfo。
+func parseFrame(fn, fileLine string) (FrameInfo, bool) {
+	m := fileLineRe.FindStringSubmatch(fileLine)
+	if m == nil || fn == "" || strings.HasPrefix(fn, "\t") {
+		return FrameInfo{}, false
+	}
+
+	line, _ := strconv.Atoi(m[2])
+	return newFrameInfo(trimArgs(fn), m[1], line), true
+}
+
+// newFrameInfo 根据完整的函数名构造 FrameInfo。
+func newFrameInfo(name, file string, line int) FrameInfo {
+	if i := strings.LastIndex(name, "/"); !strings.Contains(name[i+1:], ".") {
+		return FrameInfo{Function: name, File: file, Line: line}
+	}
+	return FrameInfo{
+		Function: funcname(name),
+		Package:  funcPackage(name),
+		File:     file,
+		Line:     line,
+	}
+}
+
+// name 返回 FrameInfo 完整的函数名。
+func (f FrameInfo) name() string {
+	if f.Package == "" {
+		return f.Function
+	}
+	return f.Package + "." + f.Function
+}
+
+// trimArgs 去除 panic 输出中函数名后的参数, 例如 main.main(0xc000010000, {0x1, 0x2})。
+func trimArgs(fn string) string {
+	if !strings.HasSuffix(fn, ")") {
+		return fn
+	}
+
+	depth := 0
+	for i := len(fn) - 1; i >= 0; i-- {
+		switch fn[i] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				return fn[:i]
+			}
+		}
+	}
+	return fn
+}
+
+// splitLines 将文本按行分割, 并去除每行末尾的 "\r"。
+func splitLines(text string) []string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
@@ -0,0 +1,234 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+const panicText = `panic: boom [recovered]
+	panic: boom again
+
+goroutine 6 [running]:
+main.(*T).M(0xc000012345, {0x4b2f1e, 0x1})
+	/tmp/p.go:7 +0x25
+panic({0x4a6f20?, 0x4e7a30?})
+	/usr/local/go/src/runtime/panic.go:792 +0x132
+main.main.func1()
+	/tmp/p.go:12 +0x51
+created by main.main in goroutine 1
+	/tmp/p.go:12 +0x7f
+
+goroutine 1 [semacquire, 5 minutes]:
+sync.runtime_Semacquire(0xc000012340?)
+	/usr/local/go/src/runtime/sema.go:71 +0x25
+main.main()
+	/tmp/p.go:13 +0x8d
+exit status 2
+`
+
+func TestParsePanic(t *testing.T) {
+	want := &ParsedPanic{
+		Values: []string{"boom", "boom again"},
+		Goroutines: []ParsedGoroutine{
+			{
+				ID:    6,
+				State: "running",
+				Stack: []FrameInfo{
+					{Function: "(*T).M", Package: "main", File: "/tmp/p.go", Line: 7},
+					{Function: "panic", File: "/usr/local/go/src/runtime/panic.go", Line: 792},
+					{Function: "main.func1", Package: "main", File: "/tmp/p.go", Line: 12},
+				},
+				CreatedBy: &FrameInfo{Function: "main", Package: "main", File: "/tmp/p.go", Line: 12},
+			},
+			{
+				ID:    1,
+				State: "semacquire, 5 minutes",
+				Stack: []FrameInfo{
+					{Function: "runtime_Semacquire", Package: "sync", File: "/usr/local/go/src/runtime/sema.go", Line: 71},
+					{Function: "main", Package: "main", File: "/tmp/p.go", Line: 13},
+				},
+			},
+		},
+	}
+
+	got := ParsePanic(panicText)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParsePanic:\n got %+v\nwant %+v", got, want)
+	}
+
+	repanicked := "panic: boom [recovered, repanicked]\n\ngoroutine 1 [running]:\nmain.main()\n\t/tmp/p.go:13 +0x8d\n"
+	if got := ParsePanic(repanicked); got == nil || !reflect.DeepEqual(got.Values, []string{"boom"}) {
+		t.Errorf("ParsePanic: want values [boom], got %+v", got)
+	}
+	if got := ParsePanic("panic: boom"); got != nil {
+		t.Errorf("ParsePanic: want nil, got %+v", got)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	root := New("ooh")
+	tests := []struct {
+		err  error
+		want []string
+	}{
+		{root, []string{"ooh"}},
+		{Wrap(io.EOF, "ahh"), []string{"EOF", "ahh"}},
+		{Wrap(Wrap(root, "ahh"), "oops"), []string{"ooh", "ahh", "oops"}},
+		{WithMessage(WithStack(root), "ahh"), []string{"ooh", "", "ahh"}},
+	}
+	for i, tt := range tests {
+		text := fmt.Sprintf("%+v", tt.err)
+		got := ParseError(text)
+		if !reflect.DeepEqual(got.Messages(), tt.want) {
+			t.Errorf("test %d: Messages: want %q, got %q", i+1, tt.want, got.Messages())
+		}
+		if want := StackTraceOf(tt.err).Info(); !reflect.DeepEqual(got.Chain[0].Stack, want) &&
+			!reflect.DeepEqual(got.Chain[1].Stack, want) {
+			t.Errorf("test %d: Stack: want %+v, got %+v", i+1, want, got.Chain)
+		}
+		if rendered := fmt.Sprintf("%+v", got); rendered != text {
+			t.Errorf("test %d: Format:\n got %q\nwant %q", i+1, rendered, text)
+		}
+	}
+
+	if got := ParseError(""); got != nil {
+		t.Errorf("ParseError: want nil, got %+v", got)
+	}
+}
+
+func TestParseErrorCompact(t *testing.T) {
+	SetStackMode(StackModeCompact)
+	defer SetStackMode(StackModeFull)
+	SetSourceContext(1)
+	defer SetSourceContext(0)
+
+	root := New("ooh")
+	err := WithStack(Wrap(root, "ahh"))
+	got := ParseError(fmt.Sprintf("%+v", err))
+	if want := []string{"ooh", "ahh", ""}; !reflect.DeepEqual(got.Messages(), want) {
+		t.Fatalf("Messages: want %q, got %q", want, got.Messages())
+	}
+
+	rootStack := StackTraceOf(root).Info()
+	if !reflect.DeepEqual(got.Chain[0].Stack, rootStack) {
+		t.Errorf("Stack: want %+v, got %+v", rootStack, got.Chain[0].Stack)
+	}
+	// 包装点的调用点, 以及还原的省略帧
+	for _, l := range got.Chain[1:] {
+		if len(l.Stack) != len(rootStack) || !reflect.DeepEqual(l.Stack[1:], rootStack[1:]) {
+			t.Errorf("Stack: want %d frames, got %+v", len(rootStack), l.Stack)
+		}
+	}
+}
+
+func TestParseError_withStack(t *testing.T) {
+	defer SetFrameFilters()
+	defer SetStackMode(StackModeFull)
+
+	tests := []struct {
+		name    string
+		filters []FrameFilter
+		mode    StackMode
+		want    int
+	}{
+		{"full", nil, StackModeFull, 3},
+		{"DropRuntime", []FrameFilter{DropRuntime()}, StackModeFull, 3},
+		{"DropStdlib", []FrameFilter{DropStdlib()}, StackModeFull, 3},
+		{"compact", nil, StackModeCompact, 3},
+		{"compact DropRuntime", []FrameFilter{DropRuntime()}, StackModeCompact, 3},
+	}
+	for _, tt := range tests {
+		SetFrameFilters(tt.filters...)
+		SetStackMode(tt.mode)
+		err := WithStack(WithStack(New("ooh")))
+		got := ParseError(fmt.Sprintf("%+v", err))
+		if len(got.Chain) != tt.want {
+			t.Errorf("%s: want %d layers, got %d: %+v", tt.name, tt.want, len(got.Chain), got.Chain)
+		}
+	}
+	SetFrameFilters()
+	SetStackMode(StackModeFull)
+
+	// 只记录调用点时, 相邻的 withStack 无法区分, 将被合并为一层
+	defer SetCapturePolicy(CapturePolicy{})
+	SetCapturePolicy(CapturePolicy{Mode: CaptureCallSite})
+	err := New("ooh")
+	err = WithStack(err)
+	err = WithStack(err)
+	got := ParseError(fmt.Sprintf("%+v", err))
+	if len(got.Chain) != 1 || len(got.Chain[0].Stack) != 3 {
+		t.Errorf("CaptureCallSite: want 1 layer with 3 frames, got %+v", got.Chain)
+	}
+}
+
+func TestParseErrorCode(t *testing.T) {
+	err := loadConfig()
+	got := ParseError(fmt.Sprintf("%+v", err))
+	want := []string{
+		"read: end of input",
+		"could not read configuration file",
+		"could not decode configuration data",
+		"service configuration could not be loaded",
+	}
+	if !reflect.DeepEqual(got.Messages(), want) {
+		t.Fatalf("Messages: want %q, got %q", want, got.Messages())
+	}
+
+	codes := []int{0, errEOF, errInvalidJSON, errConfigurationNotValid}
+	for i, l := range got.Chain {
+		if l.Code != codes[i] {
+			t.Errorf("layer %d: Code: want %d, got %d", i, codes[i], l.Code)
+		}
+	}
+	if f := got.Chain[3].Stack[0]; f.Function != "loadConfig" || f.Package != "github.com/eachinchung/errors" {
+		t.Errorf("Stack: got %+v", f)
+	}
+	if got.Chain[1].External != "end of input" {
+		t.Errorf("External: got %q", got.Chain[1].External)
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"%-v", []string{"c; d - #7 e"}},
+		{"%+v", []string{"a; b", "c; d - #7 e"}},
+	}
+	for _, tt := range tests {
+		got = ParseError(fmt.Sprintf(tt.format, WithCode(New("a; b"), errEOF, "c; d - #7 e")))
+		if !reflect.DeepEqual(got.Messages(), tt.want) {
+			t.Errorf("%s: Messages: want %q, got %q", tt.format, tt.want, got.Messages())
+		}
+	}
+}
+
+func TestParseFrames(t *testing.T) {
+	got := ParseFrames(panicText)
+	if len(got) != 6 {
+		t.Errorf("ParseFrames: want 6 frames, got %d: %+v", len(got), got)
+	}
+
+	st := stackTrace()
+	if got := ParseFrames(fmt.Sprintf("%+v", st)); !reflect.DeepEqual(got, st.Info()) {
+		t.Errorf("ParseFrames:\n got %+v\nwant %+v", got, st.Info())
+	}
+}
+
+func TestTrimArgs(t *testing.T) {
+	tests := []struct {
+		fn, want string
+	}{
+		{"main.main()", "main.main"},
+		{"main.(*T).M(0xc000012345, {0x4b2f1e, 0x1})", "main.(*T).M"},
+		{"main.(*T).M(...)", "main.(*T).M"},
+		{"main.(*T).M", "main.(*T).M"},
+		{"main.f)", "main.f)"},
+	}
+	for _, tt := range tests {
+		if got := trimArgs(tt.fn); got != tt.want {
+			t.Errorf("trimArgs(%q): want %q, got %q", tt.fn, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+func TestStackTraceOf_pkgErrors(t *testing.T) {
+	pkgErr := pkgerrors.New("ooh")
+	pkgStack := pkgErr.(interface{ StackTrace() pkgerrors.StackTrace }).StackTrace()
+	want := make(StackTrace, len(pkgStack))
+	for i, f := range pkgStack {
+		want[i] = Frame(f)
+	}
+
+	tests := []error{
+		pkgErr,
+		Wrap(pkgErr, "ahh"),
+		pkgerrors.WithMessage(WithCode(pkgErr, errEOF, "ahh"), "oops"),
+	}
+	for i, err := range tests {
+		got := StackTraceOf(err)
+		if fmt.Sprintf("%v", got) != fmt.Sprintf("%v", want) || len(got) != len(want) {
+			t.Errorf("test %d: StackTraceOf: want %v, got %v", i+1, want, got)
+		}
+	}
+
+	if hasStack(pkgerrors.WithMessage(fmt.Errorf("ooh"), "ahh")) {
+		t.Errorf("hasStack: want false for an error without stack")
+	}
+}
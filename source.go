@@ -0,0 +1,93 @@
+package errors
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// sourceContext 输出堆栈时, 每一帧前后展示的源码行数, 0 表示不展示
+var sourceContext int32
+
+// SetSourceContext 设置 %+v 输出堆栈时, 每一帧前后展示的源码行数, 用于本地开发调试。
+// 源码从磁盘读取并缓存, 源文件不存在或无法读取时将不展示源码。
+// 默认为 0, 即不展示源码。
+func SetSourceContext(lines int) {
+	if lines < 0 {
+		lines = 0
+	}
+	atomic.StoreInt32(&sourceContext, int32(lines))
+}
+
+// sourceCache 缓存已读取的源文件, 无法读取的源文件缓存为 nil
+var sourceCache = struct {
+	sync.Mutex
+	files map[string][]string
+}{files: map[string][]string{}}
+
+// sourceLines 返回源文件的所有行, 无法读取时返回 nil。
+func sourceLines(file string) []string {
+	sourceCache.Lock()
+	defer sourceCache.Unlock()
+
+	if lines, ok := sourceCache.files[file]; ok {
+		return lines
+	}
+
+	var lines []string
+	if fd, err := os.Open(file); err == nil {
+		scanner := bufio.NewScanner(fd)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		if scanner.Err() != nil {
+			lines = nil
+		}
+		_ = fd.Close()
+	}
+	sourceCache.files[file] = lines
+	return lines
+}
+
+// formatSource 输出 Frame 所在行前后 n 行的源码。
+//
+//goland:noinspection GoUnhandledErrorResult
+func (f Frame) formatSource(w io.Writer, n int) {
+	line := f.line()
+	if n <= 0 || line <= 0 {
+		return
+	}
+	io.WriteString(w, formatSourceLines(sourceLines(f.file()), line, n))
+}
+
+// formatSourceLines 返回 lines 中第 line 行前后 n 行的源码, 并以 ">" 标记第 line 行。
+// line 超出 lines 的范围时返回空字符串。
+func formatSourceLines(lines []string, line, n int) string {
+	if n <= 0 || line <= 0 || line > len(lines) {
+		return ""
+	}
+
+	first, last := line-n, line+n
+	if first < 1 {
+		first = 1
+	}
+	if last > len(lines) {
+		last = len(lines)
+	}
+
+	var b strings.Builder
+	width := len(strconv.Itoa(last))
+	for i := first; i <= last; i++ {
+		marker := " "
+		if i == line {
+			marker = ">"
+		}
+		fmt.Fprintf(&b, "\n\t%s %*d | %s", marker, width, i, strings.TrimRight(lines[i-1], " \t\r"))
+	}
+	return b.String()
+}
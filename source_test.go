@@ -0,0 +1,73 @@
+package errors
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func TestSetSourceContext(t *testing.T) {
+	SetSourceContext(1)
+	defer SetSourceContext(0)
+
+	tests := []struct {
+		arg    interface{}
+		format string
+		want   string
+	}{
+		{
+			initpc,
+			"%+v",
+			"^github.com/eachinchung/errors.init\n" +
+				"\t.+/errors/stack_test.go:10\n" +
+				"\t   9 | //goland:noinspection SpellCheckingInspection\n" +
+				"\t> 10 | var initpc = caller\\(\\)\n" +
+				"\t  11 | $",
+		},
+		{
+			initpc,
+			"%v",
+			"^stack_test.go:10$",
+		},
+		{
+			Frame(0),
+			"%+v",
+			"^unknown\n\tunknown:0$",
+		},
+		{
+			New("ooh"),
+			"%+v",
+			"^ooh\ngithub.com/eachinchung/errors.TestSetSourceContext\n" +
+				"\t.+/errors/source_test.go:\\d+\n" +
+				"\t  \\d+ | \t\t\\{\n" +
+				"\t> \\d+ | \t\t\tNew\\(\"ooh\"\\),\n" +
+				"\t  \\d+ | \t\t\t\"%\\+v\",\n",
+		},
+	}
+	for i, tt := range tests {
+		got := fmt.Sprintf(tt.format, tt.arg)
+		if !regexp.MustCompile(tt.want).MatchString(got) {
+			t.Errorf("test %d: %s:\n got %q\nwant %q", i+1, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSourceLines(t *testing.T) {
+	tests := []struct {
+		n    int
+		file string
+		line int
+		want string
+	}{
+		{0, "stack_test.go", 1, ""},
+		{2, "stack_test.go", 1, "\n\t> 1 | package errors\n\t  2 | \n\t  3 | import ("},
+		{1, "not_exist.go", 1, ""},
+		{1, "stack_test.go", 100000, ""},
+	}
+	for i, tt := range tests {
+		got := formatSourceLines(sourceLines(tt.file), tt.line, tt.n)
+		if got != tt.want {
+			t.Errorf("test %d: want %q, got %q", i+1, tt.want, got)
+		}
+	}
+}
@@ -1,12 +1,17 @@
 package errors
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"path"
+	"reflect"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
+
+	pkgerrors "github.com/pkg/errors"
 )
 
 // Frame represents a program counter inside a stack frame.
@@ -49,6 +54,37 @@ func (f Frame) name() string {
 	return fn.Name()
 }
 
+// FrameInfo 是 Frame 的结构化信息。
+type FrameInfo struct {
+	// Function 函数名, 不包含包的导入路径, 例如 (*X).ptr
+	Function string `json:"function"`
+
+	// Package 函数所在包的导入路径
+	Package string `json:"package"`
+
+	// File 经过 PathRewriter 改写的源文件路径
+	File string `json:"file"`
+
+	// Line 源文件中的行号
+	Line int `json:"line"`
+}
+
+// Info 返回 Frame 的结构化信息。
+// 无法识别的 Frame, Function 与 File 均为 "unknown"。
+func (f Frame) Info() FrameInfo {
+	name := f.name()
+	if name == "unknown" {
+		return FrameInfo{Function: name, File: name}
+	}
+
+	return FrameInfo{
+		Function: funcname(name),
+		Package:  funcPackage(name),
+		File:     f.displayFile(),
+		Line:     f.line(),
+	}
+}
+
 // Format formats the frame according to the fmt.Formatter interface.
 //
 //    %s    source file
@@ -60,7 +96,8 @@ func (f Frame) name() string {
 //
 //    %+s   function name and path of source file relative to the compile time
 //          GOPATH separated by \n\t (<funcname>\n\t<path>)
-//    %+v   equivalent to %+s:%d
+//    %+v   equivalent to %+s:%d, followed by the source lines around the frame
+//          if enabled by SetSourceContext
 //nolint:errcheck
 //goland:noinspection GoUnhandledErrorResult
 func (f Frame) Format(s fmt.State, verb rune) {
@@ -70,7 +107,7 @@ func (f Frame) Format(s fmt.State, verb rune) {
 		case s.Flag('+'):
 			io.WriteString(s, f.name())
 			io.WriteString(s, "\n\t")
-			io.WriteString(s, f.file())
+			io.WriteString(s, f.displayFile())
 		default:
 			io.WriteString(s, path.Base(f.file()))
 		}
@@ -82,6 +119,9 @@ func (f Frame) Format(s fmt.State, verb rune) {
 		f.Format(s, 's')
 		io.WriteString(s, ":")
 		f.Format(s, 'd')
+		if s.Flag('+') {
+			f.formatSource(s, int(atomic.LoadInt32(&sourceContext)))
+		}
 	}
 }
 
@@ -92,7 +132,7 @@ func (f Frame) MarshalText() ([]byte, error) {
 	if name == "unknown" {
 		return []byte(name), nil
 	}
-	return []byte(fmt.Sprintf("%s %s:%d", name, f.file(), f.line())), nil
+	return []byte(fmt.Sprintf("%s %s:%d", name, f.displayFile(), f.line())), nil
 }
 
 // StackTrace is stack of Frames from innermost (newest) to outermost (oldest).
@@ -109,6 +149,7 @@ type StackTrace []Frame
 //nolint:errcheck
 //goland:noinspection GoUnhandledErrorResult
 func (st StackTrace) Format(s fmt.State, verb rune) {
+	st = st.filter()
 	switch verb {
 	case 'v':
 		switch {
@@ -127,6 +168,21 @@ func (st StackTrace) Format(s fmt.State, verb rune) {
 	}
 }
 
+// Info 返回经过 FrameFilter 过滤后, 每个 Frame 的结构化信息。
+func (st StackTrace) Info() []FrameInfo {
+	st = st.filter()
+	info := make([]FrameInfo, len(st))
+	for i, f := range st {
+		info[i] = f.Info()
+	}
+	return info
+}
+
+// MarshalJSON 将 StackTrace 格式化为 FrameInfo 的 JSON 数组。
+func (st StackTrace) MarshalJSON() ([]byte, error) {
+	return json.Marshal(st.Info())
+}
+
 // formatSlice will format this StackTrace into the given buffer as a slice of
 // Frame, only valid when called with '%s' or '%v'.
 //nolint:errcheck
@@ -147,19 +203,28 @@ type stack []uintptr
 
 //goland:noinspection GoUnhandledErrorResult
 func (s *stack) Format(st fmt.State, verb rune) {
+	if s == nil {
+		return
+	}
+
 	switch verb {
 	case 'v':
 		switch {
 		case st.Flag('+'):
 			for _, pc := range *s {
-				f := Frame(pc)
-				fmt.Fprintf(st, "\n%+v", f)
+				if f := Frame(pc); f.keep() {
+					fmt.Fprintf(st, "\n%+v", f)
+				}
 			}
 		}
 	}
 }
 
 func (s *stack) StackTrace() StackTrace {
+	if s == nil {
+		return nil
+	}
+
 	f := make([]Frame, len(*s))
 	for i := 0; i < len(f); i++ {
 		f[i] = Frame((*s)[i])
@@ -167,14 +232,97 @@ func (s *stack) StackTrace() StackTrace {
 	return f
 }
 
-func callers() *stack {
-	const depth = 32
-	var pcs [depth]uintptr
-	n := runtime.Callers(3, pcs[:])
+const maxStackDepth = 32
+
+// capture 按照 CapturePolicy 与 StackMode 记录调用者的堆栈。
+// code 为 error 对应的错误码, cause 为被包装的 error, 没有时为 nil。
+// 不记录堆栈时, 返回 nil。
+func capture(code int, cause error) *stack {
+	return callers(4, captureDepth(code, cause))
+}
+
+// callers 记录最多 depth 帧的堆栈, skip 与 runtime.Callers 相同, depth 为 0 时返回 nil。
+func callers(skip, depth int) *stack {
+	if depth == 0 {
+		return nil
+	}
+
+	pcs := make([]uintptr, depth)
+	n := runtime.Callers(skip, pcs)
+	if n == 0 {
+		return nil
+	}
 	var st stack = pcs[0:n]
 	return &st
 }
 
+// hasStack 报告 err 树中是否有携带堆栈的 error。
+func hasStack(err error) bool {
+	return walk(err, func(err error) bool {
+		_, ok := errorStackTrace(err)
+		return ok
+	})
+}
+
+// StackTraceOf 返回 err 链中最深一个携带堆栈的 error 的 StackTrace, 即最接近错误发生处的堆栈。
+// 除本包的 error 外, 也支持 github.com/pkg/errors 的 error。
+// err 为包含多个 error 的树时, 返回第一个带有堆栈的分支中最深的堆栈。
+// 如果链中没有堆栈, 则返回 nil。
+func StackTraceOf(err error) StackTrace {
+	if err == nil {
+		return nil
+	}
+
+	for _, child := range children(err) {
+		if st := StackTraceOf(child); st != nil {
+			return st
+		}
+	}
+	if st, ok := errorStackTrace(err); ok {
+		return st
+	}
+	return nil
+}
+
+// errorStackTrace 返回 err 自身携带的 StackTrace, 未记录堆栈或 err 为 nil 指针时返回 false。
+// 除本包的 error 外, 也支持 github.com/pkg/errors 的 error, 其 StackTrace 被转换为本包的 StackTrace。
+func errorStackTrace(err error) (StackTrace, bool) {
+	if isNilPointer(err) {
+		return nil, false
+	}
+
+	switch e := err.(type) {
+	case interface{ StackTrace() StackTrace }:
+		st := e.StackTrace()
+		return st, len(st) > 0
+	case interface{ StackTrace() pkgerrors.StackTrace }:
+		pst := e.StackTrace()
+		st := make(StackTrace, len(pst))
+		for i, f := range pst {
+			st[i] = Frame(f)
+		}
+		return st, len(st) > 0
+	}
+	return nil, false
+}
+
+// isNilPointer 报告 err 是否为 nil 指针, 对其调用方法可能 panic。
+func isNilPointer(err error) bool {
+	v := reflect.ValueOf(err)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// next 返回 err 链中的下一个 error, 优先使用 Unwrap, 其次使用 Cause。
+func next(err error) error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	case interface{ Cause() error }:
+		return e.Cause()
+	}
+	return nil
+}
+
 // funcname removes the path prefix component of a function's name reported by func.Name().
 func funcname(name string) string {
 	i := strings.LastIndex(name, "/")
@@ -282,3 +282,56 @@ func caller() Frame {
 	frame, _ := frames.Next()
 	return Frame(frame.PC)
 }
+
+func TestFrameInfo(t *testing.T) {
+	tests := []struct {
+		Frame
+		want FrameInfo
+	}{
+		{
+			0,
+			FrameInfo{Function: "unknown", File: "unknown"},
+		},
+		{
+			func() Frame {
+				var x X
+				return x.ptr()
+			}(),
+			FrameInfo{Function: "(*X).ptr", Package: "github.com/eachinchung/errors", Line: 21},
+		},
+	}
+	for i, tt := range tests {
+		got := tt.Frame.Info()
+		if tt.want.Line > 0 && (got.File == "" || got.File != tt.Frame.file()) {
+			t.Errorf("test %d: Info().File: want %q, got %q", i+1, tt.Frame.file(), got.File)
+		}
+		got.File = tt.want.File
+		if got != tt.want {
+			t.Errorf("test %d: Info(): want %+v, got %+v", i+1, tt.want, got)
+		}
+	}
+}
+
+func TestStackTraceOf(t *testing.T) {
+	root := New("ooh")
+	rootStack := root.(*fundamental).StackTrace()
+
+	tests := []struct {
+		err  error
+		want StackTrace
+	}{
+		{nil, nil},
+		{fmt.Errorf("ooh"), nil},
+		{root, rootStack},
+		{Wrap(root, "ahh"), rootStack},
+		{WithMessage(WithStack(root), "ahh"), rootStack},
+		{WithCode(root, errEOF, "ahh"), rootStack},
+		{(*fundamental)(nil), nil},
+	}
+	for i, tt := range tests {
+		got := StackTraceOf(tt.err)
+		if fmt.Sprintf("%v", got) != fmt.Sprintf("%v", tt.want) || len(got) != len(tt.want) {
+			t.Errorf("test %d: StackTraceOf: want %v, got %v", i+1, tt.want, got)
+		}
+	}
+}
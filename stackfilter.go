@@ -0,0 +1,224 @@
+package errors
+
+import (
+	"go/build"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+)
+
+// FrameFilter 报告输出堆栈时是否丢弃该帧。
+type FrameFilter func(f Frame) bool
+
+// PathRewriter 改写输出堆栈时帧的源文件路径, name 为帧所在的函数名。
+type PathRewriter func(name, file string) string
+
+// frameConfig 保存堆栈输出时使用的 FrameFilter 与 PathRewriter
+type frameConfig struct {
+	filters   []FrameFilter
+	rewriters []PathRewriter
+}
+
+var frameCfg atomic.Value
+
+// SetFrameFilters 设置全局的 FrameFilter, 任一 FrameFilter 返回 true 的帧都将在输出中被丢弃。
+// 作用于 StackTrace.Format、%+v 输出的堆栈, 以及 withCode 输出的调用者。
+// 不传参数时清除所有 FrameFilter。
+func SetFrameFilters(filters ...FrameFilter) {
+	cfg := loadFrameConfig()
+	frameCfg.Store(&frameConfig{filters: filters, rewriters: cfg.rewriters})
+}
+
+// SetPathRewriters 设置全局的 PathRewriter, 按顺序依次改写源文件路径。
+// 作用于 Frame.Format、Frame.MarshalText、%+v 输出的堆栈, 以及 withCode 输出的调用者。
+// 不传参数时清除所有 PathRewriter。
+func SetPathRewriters(rewriters ...PathRewriter) {
+	cfg := loadFrameConfig()
+	frameCfg.Store(&frameConfig{filters: cfg.filters, rewriters: rewriters})
+}
+
+func loadFrameConfig() *frameConfig {
+	if cfg, ok := frameCfg.Load().(*frameConfig); ok {
+		return cfg
+	}
+	return &frameConfig{}
+}
+
+// DropPackages 返回丢弃给定包及其子包中的帧的 FrameFilter。
+func DropPackages(pkgs ...string) FrameFilter {
+	return func(f Frame) bool {
+		pkg := funcPackage(f.name())
+		for _, p := range pkgs {
+			if pkg == p || strings.HasPrefix(pkg, p+"/") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// DropRuntime 返回丢弃 runtime 包及其子包中的帧的 FrameFilter, 例如 runtime.goexit。
+func DropRuntime() FrameFilter {
+	return DropPackages("runtime")
+}
+
+// DropStdlib 返回丢弃标准库中的帧的 FrameFilter, 例如 testing.tRunner 与 net/http 的服务端帧。
+// 源文件位于 GOROOT/src 下的帧被视为标准库, 因此导入路径不包含 "." 的模块 (例如 module app) 中的帧会被保留。
+// 使用 -trimpath 构建时源文件路径不再包含 GOROOT, 此时导入路径的第一个元素不包含 "." 的包被视为标准库,
+// main 包与主模块 (见 debug.ReadBuildInfo) 中的包除外。
+func DropStdlib() FrameFilter {
+	var goroots []string
+	if root := build.Default.GOROOT; root != "" {
+		goroots = append(goroots, strings.TrimSuffix(filepath.ToSlash(root), "/")+"/src/")
+	}
+	var mainModule string
+	if info, ok := debug.ReadBuildInfo(); ok {
+		mainModule = info.Main.Path
+	}
+
+	return func(f Frame) bool {
+		name := f.name()
+		if name == "unknown" {
+			return false
+		}
+		return isStdlib(funcPackage(name), f.file(), goroots, mainModule)
+	}
+}
+
+// isStdlib 报告源文件为 file 的包 pkg 是否属于标准库, goroots 为以 "/" 结尾的 GOROOT/src 目录。
+func isStdlib(pkg, file string, goroots []string, mainModule string) bool {
+	for _, root := range goroots {
+		if strings.HasPrefix(file, root) {
+			return true
+		}
+	}
+	// 未使用 -trimpath 时, 标准库的源文件总是位于 GOROOT/src 下
+	if path.IsAbs(file) || filepath.IsAbs(file) {
+		return false
+	}
+
+	if pkg == "main" || mainModule != "" && (pkg == mainModule || strings.HasPrefix(pkg, mainModule+"/")) {
+		return false
+	}
+	if i := strings.Index(pkg, "/"); i >= 0 {
+		pkg = pkg[:i]
+	}
+	return !strings.Contains(pkg, ".")
+}
+
+// TrimPrefix 返回去除给定目录前缀的 PathRewriter。
+// 例如传入模块的根目录, 即可得到相对于模块的路径。
+func TrimPrefix(dirs ...string) PathRewriter {
+	prefixes := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		prefixes = append(prefixes, strings.TrimSuffix(filepath.ToSlash(dir), "/")+"/")
+	}
+
+	return func(_, file string) string {
+		for _, p := range prefixes {
+			if strings.HasPrefix(file, p) {
+				return file[len(p):]
+			}
+		}
+		return file
+	}
+}
+
+// TrimGOROOT 返回去除 GOROOT/src 前缀的 PathRewriter, 例如 runtime/proc.go。
+func TrimGOROOT() PathRewriter {
+	if build.Default.GOROOT == "" {
+		return func(_, file string) string { return file }
+	}
+	return TrimPrefix(filepath.Join(build.Default.GOROOT, "src"))
+}
+
+// TrimGOPATH 返回去除 GOPATH/src 与 GOPATH/pkg/mod 前缀的 PathRewriter。
+func TrimGOPATH() PathRewriter {
+	var dirs []string
+	for _, p := range filepath.SplitList(build.Default.GOPATH) {
+		dirs = append(dirs, filepath.Join(p, "src"), filepath.Join(p, "pkg", "mod"))
+	}
+	return TrimPrefix(dirs...)
+}
+
+// PackagePath 返回将源文件所在目录替换为函数所在包导入路径的 PathRewriter,
+// 例如 /Users/eachin/GolandProjects/errors/stack.go 将被改写为 github.com/eachinchung/errors/stack.go。
+func PackagePath() PathRewriter {
+	return func(name, file string) string {
+		if name == "unknown" || file == "unknown" {
+			return file
+		}
+		return funcPackage(name) + "/" + path.Base(file)
+	}
+}
+
+// keep 报告该帧是否应当在输出中保留
+func (f Frame) keep() bool {
+	for _, filter := range loadFrameConfig().filters {
+		if filter(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// displayFile 返回经过 PathRewriter 改写的源文件路径
+func (f Frame) displayFile() string {
+	file := f.file()
+	rewriters := loadFrameConfig().rewriters
+	if len(rewriters) == 0 {
+		return file
+	}
+
+	name := f.name()
+	for _, rewrite := range rewriters {
+		file = rewrite(name, file)
+	}
+	return file
+}
+
+// filter 返回丢弃 FrameFilter 匹配的帧后的 StackTrace。
+// 没有设置 FrameFilter 时, 直接返回 st 本身。
+func (st StackTrace) filter() StackTrace {
+	if len(loadFrameConfig().filters) == 0 {
+		return st
+	}
+
+	kept := make(StackTrace, 0, len(st))
+	for _, f := range st {
+		if f.keep() {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// caller 返回堆栈中第一个未被丢弃的帧, 用于 withCode 输出调用者。
+// 所有帧都被丢弃时, 返回堆栈中的第一帧; 堆栈为空时返回零值 Frame, 其输出为 unknown。
+func (s *stack) caller() Frame {
+	if len(*s) == 0 {
+		return 0
+	}
+	for _, pc := range *s {
+		if f := Frame(pc); f.keep() {
+			return f
+		}
+	}
+	return Frame((*s)[0])
+}
+
+// funcPackage 返回函数名中的包导入路径。
+// 函数名中导入路径最后一个元素的 "." 会被转义为 "%2e", 此处将其还原。
+func funcPackage(name string) string {
+	i := strings.LastIndex(name, "/")
+	j := strings.Index(name[i+1:], ".")
+	if j >= 0 {
+		name = name[:i+1+j]
+	}
+	return strings.Replace(name, "%2e", ".", -1)
+}
@@ -0,0 +1,204 @@
+package errors
+
+import (
+	"fmt"
+	"go/build"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDropPackages(t *testing.T) {
+	tests := []struct {
+		filter FrameFilter
+		frame  Frame
+		want   bool
+	}{
+		{DropPackages("github.com/eachinchung/errors"), initpc, true},
+		{DropPackages("github.com/eachinchung"), initpc, true},
+		{DropPackages("github.com/eachinchung/err"), initpc, false},
+		{DropPackages("net/http"), initpc, false},
+		{DropRuntime(), initpc, false},
+		{DropStdlib(), initpc, false},
+		{DropStdlib(), 0, false},
+	}
+	for i, tt := range tests {
+		if got := tt.filter(tt.frame); got != tt.want {
+			t.Errorf("test %d: want %v, got %v", i+1, tt.want, got)
+		}
+	}
+}
+
+func TestDropStdlib(t *testing.T) {
+	st := stackTrace()
+	var dropped []string
+	for _, f := range st {
+		if DropStdlib()(f) {
+			dropped = append(dropped, f.name())
+		}
+	}
+	want := []string{"testing.tRunner", "runtime.goexit"}
+	if fmt.Sprint(dropped) != fmt.Sprint(want) {
+		t.Errorf("DropStdlib: want %v, got %v", want, dropped)
+	}
+}
+
+func Test_isStdlib(t *testing.T) {
+	goroots := []string{"/usr/local/go/src/"}
+	tests := []struct {
+		pkg, file, mainModule string
+		want                  bool
+	}{
+		{"net/http", "/usr/local/go/src/net/http/server.go", "", true},
+		{"runtime", "/usr/local/go/src/runtime/asm_amd64.s", "", true},
+		{"app/internal/user", "/home/user/app/internal/user/user.go", "app", false},
+		{"myservice/internal/db", "/srv/myservice/internal/db/db.go", "", false},
+		{"github.com/x/y", "/home/user/go/pkg/mod/github.com/x/y@v1.0.0/y.go", "", false},
+		// -trimpath
+		{"net/http", "net/http/server.go", "app", true},
+		{"app/internal/user", "app/internal/user/user.go", "app", false},
+		{"main", "app/main.go", "app", false},
+		{"github.com/x/y", "github.com/x/y@v1.0.0/y.go", "app", false},
+	}
+	for _, tt := range tests {
+		if got := isStdlib(tt.pkg, tt.file, goroots, tt.mainModule); got != tt.want {
+			t.Errorf("isStdlib(%q, %q): want %v, got %v", tt.pkg, tt.file, tt.want, got)
+		}
+	}
+}
+
+func TestPathRewriters(t *testing.T) {
+	goroot := filepath.ToSlash(build.Default.GOROOT)
+	tests := []struct {
+		rewriter   PathRewriter
+		name, file string
+		want       string
+	}{
+		{TrimPrefix("/a/b"), "main.main", "/a/b/c/d.go", "c/d.go"},
+		{TrimPrefix("/a/b/"), "main.main", "/a/b/c/d.go", "c/d.go"},
+		{TrimPrefix("/a/b"), "main.main", "/a/bc/d.go", "/a/bc/d.go"},
+		{TrimPrefix(""), "main.main", "/a/b/c/d.go", "/a/b/c/d.go"},
+		{TrimGOROOT(), "runtime.main", goroot + "/src/runtime/proc.go", "runtime/proc.go"},
+		{PackagePath(), "github.com/eachinchung/errors.New", "/Users/eachin/GolandProjects/errors/errors.go", "github.com/eachinchung/errors/errors.go"},
+		{PackagePath(), "github.com/eachinchung/errors.(*X).ptr", "/root/module/stack_test.go", "github.com/eachinchung/errors/stack_test.go"},
+		{PackagePath(), "main.main", "_testmain.go", "main/_testmain.go"},
+		{PackagePath(), "unknown", "unknown", "unknown"},
+	}
+	for i, tt := range tests {
+		if got := tt.rewriter(tt.name, tt.file); got != tt.want {
+			t.Errorf("test %d: want %q, got %q", i+1, tt.want, got)
+		}
+	}
+}
+
+func TestTrimGOPATH(t *testing.T) {
+	gopath := filepath.SplitList(build.Default.GOPATH)
+	if len(gopath) == 0 {
+		t.Skip("GOPATH is not set")
+	}
+	dir := filepath.ToSlash(gopath[0])
+	rewrite := TrimGOPATH()
+	if got := rewrite("", dir+"/src/a/b.go"); got != "a/b.go" {
+		t.Errorf("TrimGOPATH: want %q, got %q", "a/b.go", got)
+	}
+	if got := rewrite("", dir+"/pkg/mod/a@v1.0.0/b.go"); got != "a@v1.0.0/b.go" {
+		t.Errorf("TrimGOPATH: want %q, got %q", "a@v1.0.0/b.go", got)
+	}
+}
+
+func TestFrameFiltersFormat(t *testing.T) {
+	SetFrameFilters(DropStdlib())
+	SetPathRewriters(PackagePath())
+	defer func() {
+		SetFrameFilters()
+		SetPathRewriters()
+	}()
+
+	tests := []struct {
+		arg    interface{}
+		format string
+		want   string
+	}{
+		{
+			initpc,
+			"%+v",
+			`^github.com/eachinchung/errors.init\n\tgithub.com/eachinchung/errors/stack_test.go:10$`,
+		},
+		{
+			stackTrace(),
+			"%+v",
+			`^\ngithub.com/eachinchung/errors.stackTrace\n\tgithub.com/eachinchung/errors/stack_test.go:\d+\n` +
+				`github.com/eachinchung/errors.TestFrameFiltersFormat\n\tgithub.com/eachinchung/errors/stackfilter_test.go:\d+$`,
+		},
+		{
+			stackTrace(),
+			"%s",
+			`^\[stack_test.go stackfilter_test.go\]$`,
+		},
+		{
+			New("ooh"),
+			"%+v",
+			`^ooh\ngithub.com/eachinchung/errors.TestFrameFiltersFormat\n\tgithub.com/eachinchung/errors/stackfilter_test.go:\d+$`,
+		},
+		{
+			Code(errEOF, "ooh"),
+			"%-v",
+			`^ooh - #0 \[github.com/eachinchung/errors/stackfilter_test.go:\d+ \(github.com/eachinchung/errors.TestFrameFiltersFormat\)\] \(4\) end of input$`,
+		},
+		{
+			Code(errEOF, "ooh"),
+			"%#-v",
+			`"caller":"#0 github.com/eachinchung/errors/stackfilter_test.go:\d+ \(github.com/eachinchung/errors.TestFrameFiltersFormat\)"`,
+		},
+	}
+	for i, tt := range tests {
+		got := fmt.Sprintf(tt.format, tt.arg)
+		if !regexp.MustCompile(tt.want).MatchString(got) {
+			t.Errorf("test %d: %s:\n got %q\nwant %q", i+1, tt.format, got, tt.want)
+		}
+	}
+
+	text, _ := initpc.MarshalText()
+	if !strings.HasPrefix(string(text), "github.com/eachinchung/errors.init github.com/eachinchung/errors/stack_test.go:") {
+		t.Errorf("MarshalText: got %q", text)
+	}
+}
+
+func TestStackCaller(t *testing.T) {
+	SetFrameFilters(DropPackages("github.com/eachinchung/errors"))
+	defer SetFrameFilters()
+
+	s := capture(unknownCoder.Code(), nil)
+	if got := s.caller(); got.name() != "testing.tRunner" {
+		t.Errorf("caller: want testing.tRunner, got %s", got.name())
+	}
+
+	SetFrameFilters(func(Frame) bool { return true })
+	if got := s.caller(); got != Frame((*s)[0]) {
+		t.Errorf("caller: want first frame, got %s", got.name())
+	}
+
+	empty := &stack{}
+	if got := empty.caller(); got != 0 || got.name() != "unknown" {
+		t.Errorf("caller: want zero frame for empty stack, got %s", got.name())
+	}
+}
+
+func TestFuncPackage(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"", ""},
+		{"runtime.main", "runtime"},
+		{"github.com/eachinchung/errors.funcname", "github.com/eachinchung/errors"},
+		{"github.com/eachinchung/errors.(*X).ptr", "github.com/eachinchung/errors"},
+		{"net/http.HandlerFunc.ServeHTTP", "net/http"},
+		{"gopkg.in/yaml%2ev3.Unmarshal", "gopkg.in/yaml.v3"},
+	}
+	for _, tt := range tests {
+		if got := funcPackage(tt.name); got != tt.want {
+			t.Errorf("funcPackage(%q): want %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package errors
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// StackMode 决定包装已携带堆栈的 error 时, 如何记录与输出堆栈。
+type StackMode int32
+
+const (
+	// StackModeFull 每次包装都记录完整的堆栈, 这是默认模式。
+	StackModeFull StackMode = iota
+
+	// StackModeCompact 当 cause 已携带堆栈时, Wrap、Wrapf、WithStack、WithCode 等只记录自身的调用点。
+	// %+v 输出时, 完整的堆栈只打印一次, 之后每个包装点仅打印其独有的帧,
+	// 与 cause 重复的帧以 "... N more" 代替。
+	StackModeCompact
+)
+
+// stackMode 当前的 StackMode
+var stackMode int32
+
+// SetStackMode 设置全局的 StackMode。
+func SetStackMode(mode StackMode) {
+	atomic.StoreInt32(&stackMode, int32(mode))
+}
+
+// GetStackMode 返回当前全局的 StackMode。
+func GetStackMode() StackMode {
+	return StackMode(atomic.LoadInt32(&stackMode))
+}
+
+// formatWrap 与 %+v 下的 Format 相同, 但在 StackModeCompact 下,
+// 会省略与 cause 链中最深的堆栈重复的帧。
+//
+//goland:noinspection GoUnhandledErrorResult
+func (s *stack) formatWrap(st fmt.State, verb rune, cause error) {
+	if GetStackMode() != StackModeCompact {
+		s.Format(st, verb)
+		return
+	}
+
+	inner := StackTraceOf(cause).filter()
+	if inner == nil {
+		s.Format(st, verb)
+		return
+	}
+
+	frames := s.StackTrace().filter()
+	shown, more := elide(frames, inner)
+	for _, f := range frames[:shown] {
+		fmt.Fprintf(st, "\n%+v", f)
+	}
+	if more > 0 {
+		fmt.Fprintf(st, "\n... %d more", more)
+	}
+}
+
+// elide 返回 frames 中需要打印的帧数, 以及与 inner 重复而被省略的帧数。
+//
+// 完整记录的堆栈与 inner 拥有相同的尾部, 尾部即被省略的帧;
+// 只记录了调用点的堆栈, 则以调用点所在函数在 inner 中的位置, 计算 inner 中位于其后的帧数。
+func elide(frames, inner StackTrace) (shown, more int) {
+	if len(frames) == 0 {
+		return 0, 0
+	}
+
+	n := 0
+	for n < len(frames)-1 && n < len(inner) && frames[len(frames)-1-n] == inner[len(inner)-1-n] {
+		n++
+	}
+	if n > 0 {
+		return len(frames) - n, n
+	}
+
+	last := frames[len(frames)-1].name()
+	if last == "unknown" {
+		return len(frames), 0
+	}
+	for i, f := range inner {
+		if f.name() == last {
+			return len(frames), len(inner) - i - 1
+		}
+	}
+	return len(frames), 0
+}
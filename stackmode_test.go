@@ -0,0 +1,88 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStackModeCompact(t *testing.T) {
+	SetStackMode(StackModeCompact)
+	defer SetStackMode(StackModeFull)
+
+	tests := []struct {
+		err   error
+		depth int
+	}{
+		{New("ooh"), -1},
+		{Wrap(New("ooh"), "ahh"), 1},
+		{Wrapf(New("ooh"), "ahh %d", 1), 1},
+		{WithStack(New("ooh")), 1},
+		{WithStack(Code(errEOF, "ooh")), 1},
+		{WithCode(New("ooh"), errEOF, "ahh"), 1},
+		{WithCodef(New("ooh"), errEOF, "ahh %d", 1), 1},
+		{Wrap(io.EOF, "ahh"), -1},
+		{WithCode(io.EOF, errEOF, "ahh"), -1},
+	}
+	for i, tt := range tests {
+		st := tt.err.(interface{ StackTrace() StackTrace }).StackTrace()
+		if tt.depth < 0 && len(st) <= 1 {
+			t.Errorf("test %d: want full stack, got %d frames", i+1, len(st))
+		}
+		if tt.depth >= 0 && len(st) != tt.depth {
+			t.Errorf("test %d: want %d frames, got %d", i+1, tt.depth, len(st))
+		}
+	}
+}
+
+func TestStackModeCompactFormat(t *testing.T) {
+	SetStackMode(StackModeCompact)
+	defer SetStackMode(StackModeFull)
+
+	root := New("ooh")
+	err := WithStack(Wrap(root, "ahh"))
+	got := fmt.Sprintf("%+v", err)
+	rootDepth := len(root.(interface{ StackTrace() StackTrace }).StackTrace())
+	want := fmt.Sprintf("\n... %d more", rootDepth-1)
+	if strings.Count(got, want) != 2 {
+		t.Errorf("%%+v: want two %q in\n%s", want, got)
+	}
+	if strings.Count(got, "TestStackModeCompactFormat\n") != 3 {
+		t.Errorf("%%+v: want three wrap points in\n%s", got)
+	}
+}
+
+func TestStackModeCompactFormatFull(t *testing.T) {
+	root := New("ooh")
+	err := WithStack(root)
+
+	SetStackMode(StackModeCompact)
+	defer SetStackMode(StackModeFull)
+
+	rootDepth := len(root.(interface{ StackTrace() StackTrace }).StackTrace())
+	got := fmt.Sprintf("%+v", err)
+	want := fmt.Sprintf("\n... %d more", rootDepth-1)
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("%%+v: want suffix %q in\n%s", want, got)
+	}
+}
+
+func TestElide(t *testing.T) {
+	tests := []struct {
+		frames, inner StackTrace
+		shown, more   int
+	}{
+		{nil, nil, 0, 0},
+		{StackTrace{1, 2, 3}, nil, 3, 0},
+		{StackTrace{1, 4, 5}, StackTrace{2, 3, 4, 5}, 1, 2},
+		{StackTrace{4, 5}, StackTrace{4, 5}, 1, 1},
+		{StackTrace{initpc}, StackTrace{0, initpc + 1, 0, 0}, 1, 2},
+	}
+	for i, tt := range tests {
+		shown, more := elide(tt.frames, tt.inner)
+		if shown != tt.shown || more != tt.more {
+			t.Errorf("test %d: elide: want (%d, %d), got (%d, %d)", i+1, tt.shown, tt.more, shown, more)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+//go:build go1.13
+// +build go1.13
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Summary 是按照错误码对 error 分组统计的结果, 由 GroupByCode 生成。
+type Summary struct {
+	// Total error 的总数
+	Total int
+
+	// Buckets 每个错误码的统计, 按照数量从多到少排序, 数量相同时按照错误码从小到大排序
+	Buckets []CodeBucket
+}
+
+// CodeBucket 是 Summary 中某一个错误码的统计。
+type CodeBucket struct {
+	// Coder 错误码, 未注册的错误码与没有错误码的 error 均为 ErrUnknown
+	Coder Coder
+
+	// Count 该错误码的 error 数量
+	Count int
+
+	// Sample 该错误码的第一个 error
+	Sample error
+}
+
+// GroupByCode 按照 ParseCoder 解析的错误码, 对 err 中的 error 分组统计。
+// err 为 Aggregate 或标准库 errors.Join 的结果时, 递归统计其中 (包括嵌套的 Aggregate) 的每一个 error;
+// 否则只统计 err 本身。
+// 如果 err 为 nil, 则返回 nil。
+func GroupByCode(err error) *Summary {
+	if err == nil {
+		return nil
+	}
+
+	errs, ok := members(err)
+	if !ok {
+		errs = []error{err}
+	}
+
+	s := &Summary{}
+	index := map[int]int{}
+	aggregate(errs).visit(func(err error) bool {
+		coder := ParseCoder(err)
+		i, ok := index[coder.Code()]
+		if !ok {
+			i = len(s.Buckets)
+			index[coder.Code()] = i
+			s.Buckets = append(s.Buckets, CodeBucket{Coder: coder, Sample: err})
+		}
+		s.Buckets[i].Count++
+		s.Total++
+		return false
+	})
+
+	sort.SliceStable(s.Buckets, func(i, j int) bool {
+		if s.Buckets[i].Count != s.Buckets[j].Count {
+			return s.Buckets[i].Count > s.Buckets[j].Count
+		}
+		return s.Buckets[i].Coder.Code() < s.Buckets[j].Coder.Code()
+	})
+	return s
+}
+
+// String 将 Summary 格式化为文本, 首行为总数, 其余每行为一个错误码的统计, 例如:
+//
+//	5000 errors in 2 codes
+//	    4990 x (1001) 404 资源不存在: user 42 not found
+//	      10 x (1) 500 内部服务器错误: connection refused
+func (s *Summary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d errors in %d codes", s.Total, len(s.Buckets))
+
+	width := 0
+	if len(s.Buckets) > 0 {
+		width = len(fmt.Sprint(s.Buckets[0].Count))
+	}
+	for _, bucket := range s.Buckets {
+		fmt.Fprintf(&b, "\n    %*d x (%d) %d %s: %s",
+			width,
+			bucket.Count,
+			bucket.Coder.Code(),
+			bucket.Coder.HTTPStatus(),
+			bucket.Coder.String(),
+			bucket.Sample.Error(),
+		)
+	}
+	return b.String()
+}
+
+// MarshalJSON 将 Summary 格式化为 JSON, 例如:
+//
+//	{"total":5000,"codes":[{"code":1001,"status":404,"error":"资源不存在","count":4990,"sample":"user 42 not found"}]}
+func (s *Summary) MarshalJSON() ([]byte, error) {
+	type bucket struct {
+		Code   int    `json:"code"`
+		Status int    `json:"status"`
+		Error  string `json:"error"`
+		Count  int    `json:"count"`
+		Sample string `json:"sample"`
+	}
+
+	buckets := make([]bucket, len(s.Buckets))
+	for i, b := range s.Buckets {
+		buckets[i] = bucket{
+			Code:   b.Coder.Code(),
+			Status: b.Coder.HTTPStatus(),
+			Error:  b.Coder.String(),
+			Count:  b.Count,
+			Sample: b.Sample.Error(),
+		}
+	}
+
+	return json.Marshal(struct {
+		Total int      `json:"total"`
+		Codes []bucket `json:"codes"`
+	}{s.Total, buckets})
+}
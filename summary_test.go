@@ -0,0 +1,86 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupByCode(t *testing.T) {
+	var errs []error
+	for i := 0; i < 12; i++ {
+		errs = append(errs, Codef(errBadRequest, "row %d: bad value", i))
+	}
+	errs = append(errs,
+		io.EOF,
+		NewAggregate(Code(errEOF, "eof"), Code(3000, "unregistered")),
+		Wrap(Code(errEOF, "eof"), "read"),
+	)
+
+	s := GroupByCode(NewAggregate(errs...))
+	assert.Equal(t, 16, s.Total)
+	assert.Len(t, s.Buckets, 3)
+
+	assert.Equal(t, errBadRequest, s.Buckets[0].Coder.Code())
+	assert.Equal(t, 12, s.Buckets[0].Count)
+	assert.Equal(t, "row 0: bad value", s.Buckets[0].Sample.Error())
+
+	assert.Equal(t, unknownCoder.Code(), s.Buckets[1].Coder.Code())
+	assert.Equal(t, 2, s.Buckets[1].Count)
+	assert.Equal(t, io.EOF, s.Buckets[1].Sample)
+
+	assert.Equal(t, errEOF, s.Buckets[2].Coder.Code())
+	assert.Equal(t, 2, s.Buckets[2].Count)
+
+	assert.Equal(t, "16 errors in 3 codes\n"+
+		"    12 x (2000) 400 bad request: row 0: bad value\n"+
+		"     2 x (1) 500 内部服务器错误: EOF\n"+
+		"     2 x (4) 500 end of input: eof", s.String())
+
+	b, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"total":16,"codes":[`+
+		`{"code":2000,"status":400,"error":"bad request","count":12,"sample":"row 0: bad value"},`+
+		`{"code":1,"status":500,"error":"内部服务器错误","count":2,"sample":"EOF"},`+
+		`{"code":4,"status":500,"error":"end of input","count":2,"sample":"eof"}]}`, string(b))
+}
+
+func TestGroupByCode_validation(t *testing.T) {
+	ve := testItem{"", 0}.validate()
+
+	s := GroupByCode(ve)
+	assert.Equal(t, "1 errors in 1 codes\n"+
+		"    1 x (40) 400 参数错误: [name: is required, price: must be positive]", s.String())
+
+	s = GroupByCode(NewAggregate(ve, Code(errEOF, "eof"), ve))
+	assert.Equal(t, 3, s.Total)
+	assert.Equal(t, CodeInvalidArgument, s.Buckets[0].Coder.Code())
+	assert.Equal(t, 2, s.Buckets[0].Count)
+	assert.Equal(t, ve, s.Buckets[0].Sample)
+	assert.Equal(t, errEOF, s.Buckets[1].Coder.Code())
+}
+
+func TestGroupByCode_single(t *testing.T) {
+	assert.Nil(t, GroupByCode(nil))
+
+	s := GroupByCode(Code(errEOF, "eof"))
+	assert.Equal(t, 1, s.Total)
+	assert.Equal(t, "1 errors in 1 codes\n    1 x (4) 500 end of input: eof", fmt.Sprint(s))
+}
+
+func BenchmarkGroupByCode(b *testing.B) {
+	errs := make([]error, 5000)
+	for i := range errs {
+		errs[i] = Codef(errBadRequest+i%3, "row %d", i)
+	}
+	agg := NewAggregate(errs...)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = GroupByCode(agg)
+	}
+}
@@ -0,0 +1,77 @@
+package errors
+
+// children 返回 err 在 error 树中的直接子节点。
+//
+// 依次支持以下协议:
+//
+//	Unwrap() []error    Go 1.20 的多 error, 例如 errors.Join 的结果与 Aggregate
+//	Errors() []error    未实现 Unwrap() []error 的 Aggregate
+//	Unwrap() error      Go 1.13 的 error 链
+//	Cause() error       github.com/pkg/errors 的 error 链
+func children(err error) []error {
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		return e.Unwrap()
+	case interface{ Errors() []error }:
+		return e.Errors()
+	}
+
+	if next := next(err); next != nil {
+		return []error{next}
+	}
+	return nil
+}
+
+// isMulti 报告 err 是否为包含多个 error 的节点。
+func isMulti(err error) bool {
+	switch err.(type) {
+	case interface{ Unwrap() []error }, interface{ Errors() []error }:
+		return true
+	}
+	return false
+}
+
+// walk 以深度优先的前序遍历 error 树, 当 f 返回 true 时停止遍历并返回 true。
+func walk(err error, f func(err error) bool) bool {
+	if err == nil {
+		return false
+	}
+	if f(err) {
+		return true
+	}
+
+	for _, child := range children(err) {
+		if walk(child, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// Causes 返回 error 树中每个分支的根本原因, 即树中所有的叶子节点。
+// 对于线性的 error 链, 结果只包含一个与 errors.Unwrap 链末端相同的 error。
+// 如果 err 为 nil, 则返回 nil。
+func Causes(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	var causes []error
+	var leaves func(err error)
+	leaves = func(err error) {
+		cs := children(err)
+		if len(cs) == 0 {
+			if !isMulti(err) {
+				causes = append(causes, err)
+			}
+			return
+		}
+		for _, c := range cs {
+			if c != nil {
+				leaves(c)
+			}
+		}
+	}
+	leaves(err)
+	return causes
+}
@@ -0,0 +1,76 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCauses(t *testing.T) {
+	root := New("root")
+	tests := []struct {
+		name string
+		err  error
+		want []error
+	}{
+		{
+			name: "nil",
+			err:  nil,
+			want: nil,
+		},
+		{
+			name: "chain",
+			err:  WithMessage(Wrap(root, "wrap"), "message"),
+			want: []error{root},
+		},
+		{
+			name: "go 1.13 chain",
+			err:  fmt.Errorf("wrap: %w", io.EOF),
+			want: []error{io.EOF},
+		},
+		{
+			name: "aggregate",
+			err:  Wrap(NewAggregate(Wrap(root, "wrap"), NewAggregate(io.EOF, mockAggregate{io.ErrUnexpectedEOF})), "wrap"),
+			want: []error{root, io.EOF, io.ErrUnexpectedEOF},
+		},
+		{
+			name: "empty aggregate",
+			err:  Wrap(mockAggregate{}, "wrap"),
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Causes(tt.err))
+		})
+	}
+}
+
+func TestWalk(t *testing.T) {
+	err := WithMessage(NewAggregate(io.EOF, Wrap(io.ErrUnexpectedEOF, "wrap")), "message")
+
+	var got []string
+	walk(err, func(err error) bool {
+		got = append(got, err.Error())
+		return false
+	})
+	want := []string{
+		"message: [EOF, wrap: unexpected EOF]",
+		"[EOF, wrap: unexpected EOF]",
+		"EOF",
+		"wrap: unexpected EOF",
+		"wrap: unexpected EOF",
+		"unexpected EOF",
+	}
+	assert.Equal(t, want, got)
+
+	got = nil
+	assert.True(t, walk(err, func(err error) bool {
+		got = append(got, err.Error())
+		return err == io.EOF
+	}))
+	assert.Equal(t, want[:3], got)
+	assert.False(t, walk(nil, func(error) bool { return true }))
+}
@@ -0,0 +1,250 @@
+//go:build go1.13
+// +build go1.13
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FieldError 是某个字段的校验错误。
+type FieldError struct {
+	// Path 字段的路径, 例如 items[3].price
+	Path string
+
+	// Err 字段的错误
+	Err error
+}
+
+func (e *FieldError) Error() string { return e.Path + ": " + e.Err.Error() }
+
+// Cause 返回字段的错误
+func (e *FieldError) Cause() error { return e.Err }
+
+// Unwrap 提供 Go 1.13 错误链的兼容性
+func (e *FieldError) Unwrap() error { return e.Err }
+
+//nolint:errcheck
+//goland:noinspection GoUnhandledErrorResult
+func (e *FieldError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') || s.Flag('-') || s.Flag('#') {
+			format := "%"
+			for _, flag := range "+-#" {
+				if s.Flag(int(flag)) {
+					format += string(flag)
+				}
+			}
+			fmt.Fprintf(s, "%s: "+format+"v", e.Path, e.Err)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
+// ValidationError 是以字段路径为键的校验错误, 由 Validation 生成。
+// ValidationError 实现了 Aggregate, 其中的每一个 error 均为 *FieldError。
+// ParseCoder 将其解析为 Validation 设置的错误码, 默认为 CodeInvalidArgument, 即 HTTP 400。
+type ValidationError struct {
+	aggregate
+	code int
+}
+
+func (e *ValidationError) errorCode() (int, bool) { return e.code, true }
+
+// Fields 按照添加的顺序, 返回所有出错的字段路径, 每个路径只出现一次。
+func (e *ValidationError) Fields() []string {
+	var paths []string
+	seen := map[string]struct{}{}
+	for _, err := range e.aggregate {
+		path := err.(*FieldError).Path
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		seen[path] = struct{}{}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// Field 返回路径为 path 的字段的所有错误, 该字段没有错误时返回 nil。
+func (e *ValidationError) Field(path string) []error {
+	var errs []error
+	for _, err := range e.aggregate {
+		if f := err.(*FieldError); f.Path == path {
+			errs = append(errs, f.Err)
+		}
+	}
+	return errs
+}
+
+// Format implements fmt.Formatter. https://golang.org/pkg/fmt/#hdr-Printing
+//
+// Verbs:
+//
+//	%s      与 Error() 相同
+//	%v      %s 的别名
+//	%q      带引号的 Error()
+//
+// Flags:
+//
+//	#      JSON 格式的输出, 与 json.Marshal 的结果相同, 列出每个字段的错误。
+//	       同时使用 - 或 + 时, 每个字段额外包含其错误在 withCode 中的 JSON 格式
+//	-      以 Error() 为首行, 逐个输出每个字段的错误的调用者详细信息
+//	+      以 Error() 为首行, 逐个输出每个字段的错误的完整错误堆栈详细信息
+//
+//goland:noinspection GoUnhandledErrorResult
+func (e *ValidationError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('#') {
+		b, _ := json.Marshal(e.jsonData(s.Flag('-'), s.Flag('+')))
+		s.Write(b)
+		return
+	}
+	e.aggregate.Format(s, verb)
+}
+
+// MarshalJSON 将 ValidationError 格式化为 JSON, 例如:
+//
+//	{"code":40,"error":"参数错误","fields":[{"field":"items[3].price","error":"must be positive"}]}
+//
+// error 为错误码对应的外部 (用户) 错误信息。
+func (e *ValidationError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.jsonData(false, false))
+}
+
+func (e *ValidationError) jsonData(flagDetail, flagTrace bool) map[string]interface{} {
+	coder, ok := codes[e.code]
+	if !ok {
+		coder = unknownCoder
+	}
+
+	fields := make([]map[string]interface{}, 0, len(e.aggregate))
+	for _, err := range e.aggregate {
+		f := err.(*FieldError)
+		data := map[string]interface{}{
+			"field": f.Path,
+			"error": f.Err.Error(),
+		}
+		if flagDetail || flagTrace {
+			data["chain"], _ = formatChain(f.Err, flagDetail, flagTrace, true)
+		}
+		fields = append(fields, data)
+	}
+
+	return map[string]interface{}{
+		"code":   coder.Code(),
+		"error":  coder.String(),
+		"fields": fields,
+	}
+}
+
+// Validation 用于收集以字段路径为键的校验错误, 最终生成 *ValidationError。
+// 通过 Field 与 Index 得到的子 Validation 与其父 Validation 共享收集到的错误, 路径为两者的组合,
+// 例如 v.Field("items").Index(3).Add("price", err) 添加路径为 items[3].price 的错误。
+// Validation 的零值可以直接使用, 但不是线程安全的。
+type Validation struct {
+	path string
+	root *Validation
+
+	fields []error
+	code   int
+}
+
+// NewValidation 返回一个新的 Validation。
+func NewValidation() *Validation {
+	return &Validation{}
+}
+
+// SetCode 设置生成的 ValidationError 的错误码, 默认为 CodeInvalidArgument。
+func (v *Validation) SetCode(code int) {
+	v.rootOf().code = code
+}
+
+// Field 返回路径为当前路径下名为 name 的字段的子 Validation。
+func (v *Validation) Field(name string) *Validation {
+	return &Validation{path: joinPath(v.path, name), root: v.rootOf()}
+}
+
+// Index 返回路径为当前路径下第 i 个元素的子 Validation。
+func (v *Validation) Index(i int) *Validation {
+	return v.Field("[" + strconv.Itoa(i) + "]")
+}
+
+// Add 添加当前路径下 path 字段的错误, path 为空时, 即为当前路径的错误。
+// err 为 nil 时忽略。
+// err 为 *ValidationError 时, 例如校验子结构体的结果, 其中每个字段的错误会以组合后的路径添加。
+func (v *Validation) Add(path string, err error) {
+	if err == nil {
+		return
+	}
+
+	path = joinPath(v.path, path)
+	root := v.rootOf()
+	if ve, ok := err.(*ValidationError); ok {
+		for _, e := range ve.aggregate {
+			f := e.(*FieldError)
+			root.fields = append(root.fields, &FieldError{Path: joinPath(path, f.Path), Err: f.Err})
+		}
+		return
+	}
+	root.fields = append(root.fields, &FieldError{Path: path, Err: err})
+}
+
+// Addf 根据格式说明符格式化, 添加当前路径下 path 字段以该字符串为值的错误, 与 Errorf 相同, 在它被调用的地方记录堆栈跟踪。
+func (v *Validation) Addf(path string, format string, args ...interface{}) {
+	v.Add(path, &fundamental{
+		msg:   fmt.Sprintf(format, args...),
+		stack: capture(unknownCoder.Code(), nil),
+	})
+}
+
+// Len 返回已收集的错误数量, 包括父 Validation 与其他子 Validation 收集的错误。
+func (v *Validation) Len() int {
+	return len(v.rootOf().fields)
+}
+
+// Err 将已收集的错误转换为 *ValidationError, 没有收集到任何错误时返回 nil。
+// 之后继续收集的错误不会影响已返回的 ValidationError。
+func (v *Validation) Err() error {
+	root := v.rootOf()
+	if len(root.fields) == 0 {
+		return nil
+	}
+
+	code := root.code
+	if code == 0 {
+		code = CodeInvalidArgument
+	}
+	fields := make([]error, len(root.fields))
+	copy(fields, root.fields)
+	return &ValidationError{aggregate: fields, code: code}
+}
+
+func (v *Validation) rootOf() *Validation {
+	if v.root != nil {
+		return v.root
+	}
+	return v
+}
+
+// joinPath 组合字段路径, 数组下标直接连接, 字段名以 . 连接。
+func joinPath(parent, child string) string {
+	switch {
+	case parent == "":
+		return child
+	case child == "":
+		return parent
+	case strings.HasPrefix(child, "["):
+		return parent + child
+	}
+	return parent + "." + child
+}
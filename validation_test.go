@@ -0,0 +1,172 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testItem struct {
+	Name  string
+	Price int
+}
+
+func (i testItem) validate() error {
+	v := NewValidation()
+	if i.Name == "" {
+		v.Addf("name", "is required")
+	}
+	if i.Price <= 0 {
+		v.Addf("price", "must be positive")
+	}
+	return v.Err()
+}
+
+func TestValidation(t *testing.T) {
+	items := []testItem{{"a", 1}, {"", 2}, {"c", 0}}
+
+	v := NewValidation()
+	v.Add("order", nil)
+	for i, item := range items {
+		v.Field("items").Index(i).Add("", item.validate())
+	}
+	v.Field("user").Add("email", io.EOF)
+	assert.Equal(t, 3, v.Len())
+
+	err := v.Err()
+	assert.EqualError(t, err, "[items[1].name: is required, items[2].price: must be positive, user.email: EOF]")
+
+	var ve *ValidationError
+	assert.True(t, As(err, &ve))
+	assert.Equal(t, []string{"items[1].name", "items[2].price", "user.email"}, ve.Fields())
+	assert.Len(t, ve.Errors(), 3)
+	assert.Nil(t, ve.Field("items[0].name"))
+	assert.Equal(t, []error{io.EOF}, ve.Field("user.email"))
+	assert.True(t, Is(err, io.EOF))
+
+	coder := ParseCoder(Wrap(err, "create order"))
+	assert.Equal(t, CodeInvalidArgument, coder.Code())
+	assert.Equal(t, http.StatusBadRequest, coder.HTTPStatus())
+	assert.True(t, IsCode(err, CodeInvalidArgument))
+
+	v.Addf("note", "too long")
+	assert.Len(t, ve.Errors(), 3)
+}
+
+func TestValidation_Empty(t *testing.T) {
+	var v Validation
+	assert.Nil(t, v.Err())
+	assert.Nil(t, v.Field("a").Err())
+	assert.NoError(t, testItem{"a", 1}.validate())
+}
+
+func TestValidation_SetCode(t *testing.T) {
+	v := NewValidation()
+	v.SetCode(errBadRequest)
+	v.Field("a").Add("b", io.EOF)
+
+	err := v.Err()
+	assert.Equal(t, errBadRequest, ParseCoder(err).Code())
+	assert.Equal(t, "a.b: EOF", err.Error())
+}
+
+func TestValidation_Code(t *testing.T) {
+	v := NewValidation()
+	v.Add("id", Code(errEOF, "id not found"))
+
+	err := v.Err()
+	assert.Equal(t, CodeInvalidArgument, ParseCoder(err).Code())
+	assert.True(t, IsCode(err, errEOF))
+}
+
+func TestValidationError_JSON(t *testing.T) {
+	v := NewValidation()
+	v.Field("items").Index(3).Addf("price", "must be positive")
+	v.Add("name", New("is required"))
+	err := v.Err()
+
+	want := `{"code":40,"error":"参数错误","fields":[` +
+		`{"error":"must be positive","field":"items[3].price"},` +
+		`{"error":"is required","field":"name"}]}`
+	b, e := json.Marshal(err)
+	assert.NoError(t, e)
+	assert.Equal(t, want, string(b))
+	assert.Equal(t, want, fmt.Sprintf("%#v", err))
+
+	var data struct {
+		Fields []struct {
+			Chain []map[string]interface{} `json:"chain"`
+		} `json:"fields"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(fmt.Sprintf("%#-v", err)), &data))
+	assert.Len(t, data.Fields, 2)
+	assert.Regexp(t, `^#0 .+/validation_test.go:\d+ \(.+TestValidationError_JSON\)$`, data.Fields[0].Chain[0]["caller"])
+}
+
+func TestValidationError_Format(t *testing.T) {
+	v := NewValidation()
+	v.Addf("name", "is required")
+	v.Add("age", io.EOF)
+	err := v.Err()
+
+	assert.Equal(t, "[name: is required, age: EOF]", fmt.Sprintf("%s", err))
+	assert.Equal(t, `"[name: is required, age: EOF]"`, fmt.Sprintf("%q", err))
+	assert.Regexp(t, regexp.MustCompile(`^\[name: is required, age: EOF\]\n`+
+		`\[0\] name: is required\n`+
+		`    .+TestValidationError_Format\n`+
+		`    \t.+/validation_test.go:\d+\n`+
+		`(?s:.*)`+
+		`\[1\] age: EOF$`), fmt.Sprintf("%+v", err))
+}
+
+func TestValidationError_inAggregate(t *testing.T) {
+	ve := testItem{"", 0}.validate()
+	q := New("q")
+	err := NewAggregate(ve, NewAggregate(q, ve), io.EOF)
+
+	var ves []*ValidationError
+	assert.True(t, AsAll(err, &ves))
+	assert.Len(t, ves, 2)
+	var fields []*FieldError
+	assert.True(t, AsAll(err, &fields))
+	assert.Len(t, fields, 4)
+
+	assert.EqualError(t, err, "[[name: is required, price: must be positive], q, EOF]")
+	assert.True(t, Is(err, io.EOF))
+	assert.True(t, IsCode(err, CodeInvalidArgument))
+
+	flat := Flatten(err)
+	assert.Equal(t, []error{ve, q, ve, io.EOF}, flat.Errors())
+	assert.Equal(t, []error{NewAggregate(q), io.EOF}, FilterOut(err, MatchCode(CodeInvalidArgument)).(Aggregate).Errors())
+
+	var data []interface{}
+	assert.NoError(t, json.Unmarshal([]byte(fmt.Sprintf("%#v", NewAggregate(ve, q))), &data))
+	assert.Equal(t, map[string]interface{}{
+		"code":  float64(CodeInvalidArgument),
+		"error": "参数错误",
+		"fields": []interface{}{
+			map[string]interface{}{"field": "name", "error": "is required"},
+			map[string]interface{}{"field": "price", "error": "must be positive"},
+		},
+	}, data[0])
+}
+
+func Test_joinPath(t *testing.T) {
+	tests := []struct {
+		parent, child, want string
+	}{
+		{"", "a", "a"},
+		{"a", "", "a"},
+		{"a", "b", "a.b"},
+		{"a", "[1]", "a[1]"},
+		{"a[1]", "b.c", "a[1].b.c"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, joinPath(tt.parent, tt.child))
+	}
+}